@@ -2,16 +2,19 @@
 
 # Build the application
 echo "Building..."
-go build -o multi-cmd ./cmd/multi-cmd
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+go build -ldflags "-X main.version=${VERSION}" -o multi-cmd ./cmd/multi-cmd
 
 if [ $? -eq 0 ]; then
     echo "✅ Build successful!"
     echo ""
     echo "Usage examples:"
-    echo "  ./multi-cmd                            # Scan current directory"
-    echo "  ./multi-cmd /path/to/open              # Scan specific directory"
-    echo "  ./multi-cmd . commands.yaml            # Use custom config"
-    echo "  ./multi-cmd . commands.yaml out.md     # Custom config and output"
+    echo "  ./multi-cmd                                  # Scan current directory"
+    echo "  ./multi-cmd /path/to/open                    # Scan specific directory"
+    echo "  ./multi-cmd --config my.yaml .               # Use custom config"
+    echo "  ./multi-cmd --output out.md .                # Custom output file"
+    echo "  ./multi-cmd run --commands 'Git Status' ..   # Headless run"
+    echo "  ./multi-cmd --help                           # All commands and flags"
     echo ""
     echo "Try it now:"
     echo "  ./multi-cmd .."
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ramayac/multi-cmd/internal/config"
+	"github.com/ramayac/multi-cmd/internal/scanner"
+)
+
+// runListCommands prints the name and command line of every configured command
+func runListCommands(args []string) int {
+	var opts cliOptions
+	fs := newFlagSet("list-commands", "")
+	opts.addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, cmd := range cfg.Commands {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", cmd.Name, strings.Join(cmd.Tags, ","), strings.Join(append([]string{cmd.Cmd + cmd.Shell}, cmd.Args...), " "))
+	}
+	w.Flush()
+
+	if len(cfg.Profiles) > 0 {
+		fmt.Println()
+		fmt.Println("Profiles:")
+		for _, profile := range cfg.Profiles {
+			commands, err := config.ProfileCommands(cfg, &profile)
+			if err != nil {
+				return fail(err)
+			}
+			names := make([]string, len(commands))
+			for i, cmd := range commands {
+				names[i] = cmd.Name
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", profile.Name, strings.Join(names, ", "), strings.Join(profile.Folders, " "))
+		}
+		w.Flush()
+	}
+	return 0
+}
+
+// runListFolders prints the folders that the TUI would offer
+func runListFolders(args []string) int {
+	var opts cliOptions
+	fs := newFlagSet("list-folders", "[scan path...]")
+	opts.addConfigFlags(fs)
+	opts.addScanFlags(fs)
+	if err := opts.parse(fs, args); err != nil {
+		return parseExitCode(err)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(err)
+	}
+
+	scanOptions, err := opts.scanOptions(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, folder := range scanner.Scan(scanOptions) {
+		if folder.Git != nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", folder.Name, folder.Git.Branch, scanner.GitSummary(folder.Git), folder.Path)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", folder.Name, folder.Path)
+	}
+	w.Flush()
+	return 0
+}
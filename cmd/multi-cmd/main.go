@@ -1,54 +1,238 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
 	"os"
-	"path/filepath"
-	"time"
+	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ramayac/multi-cmd/internal/config"
-	"github.com/ramayac/multi-cmd/internal/tui"
+	"github.com/ramayac/multi-cmd/internal/executor"
+	"github.com/ramayac/multi-cmd/internal/models"
+	"github.com/ramayac/multi-cmd/internal/scanner"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var subcommands = []subcommand{
+	{"tui", "Interactive folder and command picker (default)", runTUI},
+	{"run", "Run commands without the TUI and write the report", runHeadless},
+	{"list-commands", "Print the commands defined in the config file", runListCommands},
+	{"list-folders", "Print the folders found in the scan path", runListFolders},
+	{"validate", "Check the config file and exit non-zero on problems", runValidate},
+}
+
 func main() {
-	// Default configuration
-	configPath := "commands.yaml"
-	scanPath := "."
-	outputPath := fmt.Sprintf("multi-cmd-results-%s.md", time.Now().Format("2006-01-02-150405"))
+	os.Exit(dispatch(os.Args[1:]))
+}
 
-	defer fmt.Print("\033[H\033[2J")
+func dispatch(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "-help", "--help", "help":
+			usage()
+			return 0
+		case "-version", "--version", "version":
+			fmt.Printf("multi-cmd %s\n", version)
+			return 0
+		}
 
-	// Parse command line arguments
-	if len(os.Args) > 1 {
-		scanPath = os.Args[1]
+		for _, sub := range subcommands {
+			if args[0] == sub.name {
+				return sub.run(args[1:])
+			}
+		}
 	}
-	if len(os.Args) > 2 {
-		configPath = os.Args[2]
+
+	return runTUI(args)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: multi-cmd [command] [flags] [scan path...]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, sub := range subcommands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", sub.name, sub.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'multi-cmd <command> --help' for the flags of a command.")
+	fmt.Fprintln(os.Stderr, "Use --version to print the version.")
+}
+
+// cliOptions holds the flags shared by the subcommands
+type cliOptions struct {
+	scanPaths   stringList
+	folderList  string
+	configPath  string
+	outputPath  string
+	format      string
+	concurrency int
+	onFailure   string
+	spillDir    string
+	maxDepth    int
+	hidden      bool
+	gitOnly     bool
+	workspaces  bool
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: multi-cmd %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
 	}
-	if len(os.Args) > 3 {
-		outputPath = os.Args[3]
+	return fs
+}
+
+func (o *cliOptions) addConfigFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", "", "path to the commands file (default: $XDG_CONFIG_HOME/multi-cmd/commands.yaml if present, else ./commands.yaml)")
+}
+
+func (o *cliOptions) addScanFlags(fs *flag.FlagSet) {
+	fs.Var(&o.scanPaths, "scan", "directory whose subfolders are listed; repeat for several roots (may also be given as arguments, default: .)")
+	fs.StringVar(&o.folderList, "folder-list", "", "file listing folder paths, one per line or a YAML folders: section")
+	fs.IntVar(&o.maxDepth, "depth", 0, "directory levels to search for project roots (default: max_depth from config, or 1)")
+	fs.BoolVar(&o.hidden, "hidden", false, "include folders whose name starts with a dot")
+	fs.BoolVar(&o.gitOnly, "git-only", false, "list only git working trees, with branch and status")
+	fs.BoolVar(&o.workspaces, "workspaces", false, "list the members of go.work, npm/pnpm and Cargo workspaces instead of the workspace folder")
+}
+
+func (o *cliOptions) addRunFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.outputPath, "output", "", "report file (default: timestamped file in the current directory)")
+	fs.StringVar(&o.format, "format", "", "report format: markdown, json, ndjson or junit (default: from output file extension)")
+	fs.IntVar(&o.concurrency, "concurrency", 0, "number of commands to run in parallel (default: concurrency from config, or CPU count)")
+	fs.StringVar(&o.spillDir, "spill-dir", "", "directory receiving the full output of commands whose output was truncated (default: spill_dir from config)")
+	fs.StringVar(&o.onFailure, "on-failure", "", "after a failure: continue, stop_folder or stop_all (default: on_failure from config, or continue)")
+}
+
+// parse parses the flags; trailing arguments are extra scan roots
+func (o *cliOptions) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
 
-	// Convert to absolute path
-	absPath, err := filepath.Abs(scanPath)
-	if err != nil {
-		log.Fatalf("Invalid scan path: %v", err)
+	o.scanPaths = append(o.scanPaths, fs.Args()...)
+	return nil
+}
+
+// parseExitCode maps a flag parsing error to an exit code. The flag package
+// has already printed the problem and usage.
+func parseExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// scanOptions combines the folder discovery settings of the config file with
+// the scan roots and folder list given on the command line. The current
+// directory is scanned when no roots or folders are given anywhere. Roots
+// must be existing directories, so that a mistyped path or the old
+// "multi-cmd <dir> <config> <output>" form is not silently ignored.
+func (o *cliOptions) scanOptions(cfg *models.Config) (scanner.Options, error) {
+	opts := scanner.OptionsFromConfig(cfg)
+	opts.Roots = o.scanPaths
+	for _, root := range opts.Roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return opts, fmt.Errorf("scan root: %w", err)
+		}
+		if !info.IsDir() {
+			return opts, fmt.Errorf("scan root %s is not a directory", root)
+		}
+	}
+	opts.Folders = append([]string(nil), cfg.Folders...)
+
+	if o.folderList != "" {
+		paths, err := scanner.ReadFolderList(o.folderList)
+		if err != nil {
+			return opts, err
+		}
+		opts.Folders = append(opts.Folders, paths...)
 	}
 
-	// Load configuration
-	cfg, err := config.Load(configPath)
+	if len(opts.Roots) == 0 && len(opts.Folders) == 0 {
+		opts.Roots = []string{"."}
+	}
+	return opts, nil
+}
+
+func (o *cliOptions) reportFormat() (executor.Format, error) {
+	if o.format == "" {
+		return "", nil
+	}
+	return executor.ParseFormat(o.format)
+}
+
+func (o *cliOptions) loadConfig() (*models.Config, error) {
+	if o.configPath == "" {
+		o.configPath = config.DefaultPath()
+	}
+
+	cfg, err := config.Load(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if o.concurrency > 0 {
+		cfg.Concurrency = o.concurrency
 	}
+	if o.spillDir != "" {
+		cfg.SpillDir = o.spillDir
+	}
+	if o.onFailure != "" {
+		policy, err := config.ParsePolicy(o.onFailure)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OnFailure = policy
+	}
+	if o.maxDepth > 0 {
+		cfg.MaxDepth = o.maxDepth
+	}
+	if o.hidden {
+		cfg.IncludeHidden = true
+	}
+	if o.gitOnly {
+		cfg.GitOnly = true
+	}
+	if o.workspaces {
+		cfg.Workspaces = true
+	}
+	return cfg, nil
+}
 
-	// Clear the console before starting
-	fmt.Print("\033[H\033[2J")
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "multi-cmd: %v\n", err)
+	return 2
+}
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
 
-	// Initialize and run TUI
-	p := tea.NewProgram(tui.NewModel(absPath, configPath, outputPath, cfg))
-	if _, err := p.Run(); err != nil {
-		log.Fatalf("Error running program: %v", err)
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
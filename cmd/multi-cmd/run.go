@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ramayac/multi-cmd/internal/config"
+	"github.com/ramayac/multi-cmd/internal/executor"
+	"github.com/ramayac/multi-cmd/internal/models"
+	"github.com/ramayac/multi-cmd/internal/scanner"
+)
+
+// runHeadless executes commands without the TUI. It returns the process exit
+// code: 0 when every command succeeded, 1 when any failed and 2 on usage or
+// setup errors.
+func runHeadless(args []string) int {
+	var opts cliOptions
+	var folderPatterns, commandNames, commandTags, profileName string
+	fs := newFlagSet("run", "[scan path...]")
+	opts.addConfigFlags(fs)
+	opts.addScanFlags(fs)
+	opts.addRunFlags(fs)
+	fs.StringVar(&folderPatterns, "folders", "", "comma-separated glob patterns of folder names (default: all folders)")
+	fs.StringVar(&commandNames, "commands", "", "comma-separated command names (default: all commands)")
+	fs.StringVar(&commandTags, "tags", "", "comma-separated tags; commands carrying any of them are run")
+	fs.StringVar(&profileName, "profile", "", "named profile from the config; --commands, --tags and --folders override its parts")
+	if err := opts.parse(fs, args); err != nil {
+		return parseExitCode(err)
+	}
+
+	format, err := opts.reportFormat()
+	if err != nil {
+		return fail(err)
+	}
+	outputPath := opts.outputPath
+	if outputPath == "" {
+		outputPath = executor.DefaultOutputPath(format)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(err)
+	}
+
+	scanOptions, err := opts.scanOptions(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	commands := cfg.Commands
+	patterns := splitList(folderPatterns)
+	if profileName != "" {
+		profile, err := config.FindProfile(cfg, profileName)
+		if err != nil {
+			return fail(err)
+		}
+		if commands, err = config.ProfileCommands(cfg, profile); err != nil {
+			return fail(err)
+		}
+		if len(patterns) == 0 {
+			patterns = profile.Folders
+		}
+	}
+	if commandNames != "" || commandTags != "" {
+		if commands, err = config.MatchCommands(cfg, splitList(commandNames), splitList(commandTags)); err != nil {
+			return fail(err)
+		}
+	}
+	commands = config.WithDependencies(cfg, commands)
+
+	folders, err := scanner.Select(scanner.Scan(scanOptions), patterns)
+	if err != nil {
+		return fail(fmt.Errorf("invalid folder pattern: %w", err))
+	}
+
+	folderCount := 0
+	for _, folder := range folders {
+		if folder.Selected {
+			folderCount++
+		}
+	}
+	if folderCount == 0 || len(commands) == 0 {
+		return fail(fmt.Errorf("nothing to run: %d folders and %d commands selected", folderCount, len(commands)))
+	}
+
+	writer, err := executor.NewReportWriter(format, outputPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	total := folderCount * len(commands)
+	completed := 0
+	var writeErr error
+	execOpts := executor.OptionsFromConfig(cfg)
+	execOpts.OnResult = func(result models.ExecutionResult) {
+		completed++
+		fmt.Fprintf(os.Stderr, "[%d/%d] %-9s %s: %s (%s)\n",
+			completed, total, result.Status, result.FolderName, result.CommandName,
+			executor.FormatTiming(result))
+		if err := writer.Add(result); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+	results := executor.Execute(ctx, folders, commands, execOpts)
+
+	if err := writer.Close(results); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		return fail(fmt.Errorf("error writing results: %w", writeErr))
+	}
+
+	failed, skipped := 0, 0
+	for _, result := range results {
+		switch {
+		case result.Status == models.StatusSkipped:
+			skipped++
+		case !result.Success:
+			failed++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Results written to: %s\n", outputPath)
+	fmt.Fprintf(os.Stderr, "Executed: %d commands on %d folders | Success: %d | Failed: %d | Skipped: %d\n",
+		len(commands), folderCount, len(results)-failed-skipped, failed, skipped)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ramayac/multi-cmd/internal/tui"
+)
+
+// runTUI starts the interactive Bubble Tea program
+func runTUI(args []string) int {
+	var opts cliOptions
+	fs := newFlagSet("tui", "[scan path...]")
+	opts.addConfigFlags(fs)
+	opts.addScanFlags(fs)
+	opts.addRunFlags(fs)
+	if err := opts.parse(fs, args); err != nil {
+		return parseExitCode(err)
+	}
+
+	format, err := opts.reportFormat()
+	if err != nil {
+		return fail(err)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(err)
+	}
+
+	scanOptions, err := opts.scanOptions(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Clear the console before starting and after exiting
+	fmt.Print("\033[H\033[2J")
+	defer fmt.Print("\033[H\033[2J")
+
+	p := tea.NewProgram(tui.NewModel(scanOptions, opts.configPath, opts.outputPath, format, cfg))
+	if _, err := p.Run(); err != nil {
+		return fail(fmt.Errorf("error running program: %w", err))
+	}
+	return 0
+}
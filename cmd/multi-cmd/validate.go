@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ramayac/multi-cmd/internal/config"
+)
+
+// runValidate checks the config file, printing every problem found. It exits
+// with 1 when there are problems.
+func runValidate(args []string) int {
+	var opts cliOptions
+	fs := newFlagSet("validate", "")
+	opts.addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return parseExitCode(err)
+	}
+	if opts.configPath == "" {
+		opts.configPath = config.DefaultPath()
+	}
+
+	cfg, problems := config.Validate(opts.configPath)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("%s: %d problem(s) found\n", opts.configPath, len(problems))
+		return 1
+	}
+
+	fmt.Printf("%s: OK (%d commands, %d profiles)\n", opts.configPath, len(cfg.Commands), len(cfg.Profiles))
+	return 0
+}
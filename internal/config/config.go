@@ -1,28 +1,386 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/ramayac/multi-cmd/internal/models"
 	"gopkg.in/yaml.v3"
 )
 
-// Load reads and parses the configuration file
+// DefaultFileName is the config file looked up when none is given
+const DefaultFileName = "commands.yaml"
+
+// UserPath returns the per-user config file location,
+// $XDG_CONFIG_HOME/multi-cmd/commands.yaml (~/.config when unset).
+func UserPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "multi-cmd", DefaultFileName)
+}
+
+// DefaultPath returns the user-level config file when it exists and falls
+// back to commands.yaml in the current directory.
+func DefaultPath() string {
+	if userPath := UserPath(); userPath != "" {
+		if _, err := os.Stat(userPath); err == nil {
+			return userPath
+		}
+	}
+	return DefaultFileName
+}
+
+// Load reads and parses the configuration file together with the files it
+// includes. Included files are merged first, in order, and the including
+// file is layered on top of them. Every problem found is reported in the
+// returned error.
 func Load(configPath string) (*models.Config, error) {
+	l := newLoader()
+	cfg := l.load(configPath)
+	if cfg != nil {
+		l.checkMerged(configPath, cfg)
+	}
+
+	if len(l.problems) > 0 {
+		errs := make([]error, len(l.problems))
+		for i, problem := range l.problems {
+			errs[i] = problem
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	return cfg, nil
+}
+
+// Validate loads the configuration like Load, additionally checks that every
+// command's binary can be found on PATH, and returns all problems found.
+func Validate(configPath string) (*models.Config, []Problem) {
+	l := newLoader()
+	cfg := l.load(configPath)
+	if cfg != nil {
+		l.checkMerged(configPath, cfg)
+		l.checkBinaries(cfg)
+	}
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := l.problems[i], l.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return cfg, l.problems
+}
+
+// loader reads a config file and its includes, collecting problems rather
+// than stopping at the first one.
+type loader struct {
+	loading  map[string]bool
+	problems []Problem
+	// commands and profiles remember where each name was last defined
+	commands map[string]Position
+	profiles map[string]Position
+}
+
+func newLoader() *loader {
+	return &loader{
+		loading:  make(map[string]bool),
+		commands: make(map[string]Position),
+		profiles: make(map[string]Position),
+	}
+}
+
+// load returns nil when the file cannot be read or parsed at all
+func (l *loader) load(configPath string) *models.Config {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		l.add(Position{File: configPath}, "invalid config path: %v", err)
+		return nil
+	}
+	if l.loading[absPath] {
+		l.add(Position{File: configPath}, "include cycle through %s", configPath)
+		return nil
+	}
+	l.loading[absPath] = true
+	defer delete(l.loading, absPath)
+
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		l.add(Position{File: configPath}, "failed to read config file: %v", err)
+		return nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		l.addYAMLError(configPath, err, nil)
+		return nil
 	}
 
 	var cfg models.Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	var doc *yaml.Node
+	if len(root.Content) > 0 {
+		doc = root.Content[0]
+		l.checkKeys(configPath, doc, reflect.TypeOf(cfg))
+		if err := doc.Decode(&cfg); err != nil {
+			l.addYAMLError(configPath, err, doc)
+		}
+	}
+
+	// Folder, spill and include paths are relative to the config file
+	baseDir := filepath.Dir(configPath)
+	for i, folder := range cfg.Folders {
+		if !filepath.IsAbs(folder) {
+			cfg.Folders[i] = filepath.Join(baseDir, folder)
+		}
+	}
+	if cfg.SpillDir != "" && !filepath.IsAbs(cfg.SpillDir) {
+		cfg.SpillDir = filepath.Join(baseDir, cfg.SpillDir)
+	}
+
+	merged := &models.Config{}
+	for _, pattern := range cfg.Include {
+		paths, err := includePaths(baseDir, pattern)
+		if err != nil {
+			l.add(Position{File: configPath}, "%v", err)
+			continue
+		}
+
+		for _, path := range paths {
+			if included := l.load(path); included != nil {
+				merge(merged, included)
+			}
+		}
+	}
+	merge(merged, &cfg)
+
+	// Checked after the includes so that positions point at the overrides
+	l.checkFile(configPath, doc)
+
+	return merged
+}
+
+// includePaths resolves an include entry. Globs may match nothing; plain
+// paths must exist.
+func includePaths(baseDir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
 	}
 
-	if len(cfg.Commands) == 0 {
-		return nil, fmt.Errorf("no commands defined in config file")
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("include %s: %w", pattern, err)
+		}
+		return []string{pattern}, nil
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %w", pattern, err)
 	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// merge layers overlay on top of base. Commands and profiles replace the
+// base entry with the same name in place and are appended otherwise.
+// Settings that are set in overlay win, vars and env are merged by name and list
+// settings are appended.
+func merge(base, overlay *models.Config) {
+	base.Commands = mergeNamed(base.Commands, overlay.Commands, func(c models.Command) string { return c.Name })
+	base.Profiles = mergeNamed(base.Profiles, overlay.Profiles, func(p models.Profile) string { return p.Name })
 
-	return &cfg, nil
+	base.Vars = mergeMap(base.Vars, overlay.Vars)
+	base.Env = mergeMap(base.Env, overlay.Env)
+	if overlay.Concurrency != 0 {
+		base.Concurrency = overlay.Concurrency
+	}
+	if overlay.OnFailure != "" {
+		base.OnFailure = overlay.OnFailure
+	}
+	if overlay.Timeout != 0 {
+		base.Timeout = overlay.Timeout
+	}
+	if overlay.MaxOutput != 0 {
+		base.MaxOutput = overlay.MaxOutput
+	}
+	if overlay.SpillDir != "" {
+		base.SpillDir = overlay.SpillDir
+	}
+	if overlay.MaxDepth != 0 {
+		base.MaxDepth = overlay.MaxDepth
+	}
+	if len(overlay.Markers) > 0 {
+		base.Markers = overlay.Markers
+	}
+	base.Ignore = append(base.Ignore, overlay.Ignore...)
+	base.Folders = append(base.Folders, overlay.Folders...)
+	base.IncludeHidden = base.IncludeHidden || overlay.IncludeHidden
+	base.GitOnly = base.GitOnly || overlay.GitOnly
+	base.Workspaces = base.Workspaces || overlay.Workspaces
+}
+
+func mergeMap(base, overlay map[string]string) map[string]string {
+	for name, value := range overlay {
+		if base == nil {
+			base = make(map[string]string)
+		}
+		base[name] = value
+	}
+	return base
+}
+
+func mergeNamed[T any](base, overlay []T, name func(T) string) []T {
+	index := make(map[string]int)
+	for i, item := range base {
+		index[name(item)] = i
+	}
+
+	for _, item := range overlay {
+		if i, ok := index[name(item)]; ok {
+			base[i] = item
+			continue
+		}
+		index[name(item)] = len(base)
+		base = append(base, item)
+	}
+	return base
+}
+
+// SelectCommands returns the commands with the given names, in config order.
+// With no names every command is returned.
+func SelectCommands(cfg *models.Config, names []string) ([]models.Command, error) {
+	if len(names) == 0 {
+		return cfg.Commands, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var selected []models.Command
+	for _, cmd := range cfg.Commands {
+		if wanted[cmd.Name] {
+			selected = append(selected, cmd)
+			delete(wanted, cmd.Name)
+		}
+	}
+
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("unknown command %q", name)
+		}
+	}
+
+	return selected, nil
+}
+
+// MatchCommands returns the commands selected by name or carrying any of the
+// tags, in config order. Unknown names are an error.
+func MatchCommands(cfg *models.Config, names, tags []string) ([]models.Command, error) {
+	named := make(map[string]bool)
+	if len(names) > 0 {
+		byName, err := SelectCommands(cfg, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, cmd := range byName {
+			named[cmd.Name] = true
+		}
+	}
+
+	var selected []models.Command
+	for _, cmd := range cfg.Commands {
+		if named[cmd.Name] || hasAnyTag(cmd, tags) {
+			selected = append(selected, cmd)
+		}
+	}
+	return selected, nil
+}
+
+func hasAnyTag(cmd models.Command, tags []string) bool {
+	for _, tag := range tags {
+		for _, cmdTag := range cmd.Tags {
+			if tag == cmdTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithDependencies adds the commands that the given ones depend on,
+// directly or indirectly, and returns them all in config order
+func WithDependencies(cfg *models.Config, commands []models.Command) []models.Command {
+	byName := make(map[string]models.Command)
+	for _, cmd := range cfg.Commands {
+		byName[cmd.Name] = cmd
+	}
+
+	wanted := make(map[string]bool)
+	var add func(cmd models.Command)
+	add = func(cmd models.Command) {
+		if wanted[cmd.Name] {
+			return
+		}
+		wanted[cmd.Name] = true
+		for _, dep := range cmd.DependsOn {
+			if depCmd, ok := byName[dep]; ok {
+				add(depCmd)
+			}
+		}
+	}
+	for _, cmd := range commands {
+		add(cmd)
+	}
+
+	var selected []models.Command
+	for _, cmd := range cfg.Commands {
+		if wanted[cmd.Name] {
+			selected = append(selected, cmd)
+		}
+	}
+	return selected
+}
+
+// ParsePolicy checks an on_failure value. An empty value means continue.
+func ParsePolicy(name string) (models.FailurePolicy, error) {
+	switch policy := models.FailurePolicy(name); policy {
+	case "", models.PolicyContinue:
+		return models.PolicyContinue, nil
+	case models.PolicyStopFolder, models.PolicyStopAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown on_failure policy %q (want continue, stop_folder or stop_all)", name)
+	}
+}
+
+// FindProfile looks up a profile by name
+func FindProfile(cfg *models.Config, name string) (*models.Profile, error) {
+	for i := range cfg.Profiles {
+		if cfg.Profiles[i].Name == name {
+			return &cfg.Profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown profile %q", name)
+}
+
+// ProfileCommands returns the commands a profile selects, in config order
+func ProfileCommands(cfg *models.Config, profile *models.Profile) ([]models.Command, error) {
+	commands, err := MatchCommands(cfg, profile.Commands, profile.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", profile.Name, err)
+	}
+	return commands, nil
 }
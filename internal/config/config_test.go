@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// writeFiles creates the files under a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func commandNames(commands []models.Command) string {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestLoadIncludes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shared/base.yaml": `
+timeout: 1m
+concurrency: 2
+vars: {owner: base, region: eu}
+ignore: [vendor]
+folders: [tools]
+commands:
+  - {name: status, cmd: git, args: [status]}
+  - {name: build, cmd: make}
+`,
+		"teams/a.yaml": `
+commands:
+  - {name: a-only, cmd: "true"}
+`,
+		"teams/b.yaml": `
+commands:
+  - {name: b-only, cmd: "true"}
+`,
+		"commands.yaml": `
+include: [shared/base.yaml, "teams/*.yaml"]
+concurrency: 8
+vars: {owner: top}
+ignore: [node_modules]
+commands:
+  - {name: build, cmd: go, args: [build, ./...]}
+  - {name: test, cmd: go, args: [test, ./...]}
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "commands.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Overrides stay in place, new commands are appended in include order
+	if got, want := commandNames(cfg.Commands), "status,build,a-only,b-only,test"; got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+	if got := cfg.Commands[1].Cmd; got != "go" {
+		t.Errorf("build runs %q, want the overriding go", got)
+	}
+	if cfg.Timeout != time.Minute || cfg.Concurrency != 8 {
+		t.Errorf("timeout %s and concurrency %d, want 1m and 8", cfg.Timeout, cfg.Concurrency)
+	}
+	if cfg.Vars["owner"] != "top" || cfg.Vars["region"] != "eu" {
+		t.Errorf("vars = %v, want owner from the top file and region from the include", cfg.Vars)
+	}
+	if got := strings.Join(cfg.Ignore, ","); got != "vendor,node_modules" {
+		t.Errorf("ignore = %s, want both lists appended", got)
+	}
+	if len(cfg.Folders) != 1 || cfg.Folders[0] != filepath.Join(dir, "shared", "tools") {
+		t.Errorf("folders = %v, want paths relative to the including file", cfg.Folders)
+	}
+}
+
+func TestLoadIncludeProblems(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "missing include",
+			files: map[string]string{
+				"commands.yaml": "include: [missing.yaml]\ncommands: [{name: a, cmd: \"true\"}]\n",
+			},
+			want: "include ",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"commands.yaml": "include: [other.yaml]\ncommands: [{name: a, cmd: \"true\"}]\n",
+				"other.yaml":    "include: [commands.yaml]\n",
+			},
+			want: "include cycle through",
+		},
+		{
+			name: "glob matching nothing",
+			files: map[string]string{
+				"commands.yaml": "include: [\"teams/*.yaml\"]\ncommands: [{name: a, cmd: \"true\"}]\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := Load(filepath.Join(dir, "commands.yaml"))
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Load: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Load error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeNamed(t *testing.T) {
+	base := []models.Command{{Name: "a", Cmd: "1"}, {Name: "b", Cmd: "1"}}
+	overlay := []models.Command{{Name: "c", Cmd: "2"}, {Name: "a", Cmd: "2"}}
+
+	merged := mergeNamed(base, overlay, func(c models.Command) string { return c.Name })
+	if got, want := commandNames(merged), "a,b,c"; got != want {
+		t.Errorf("merged = %s, want %s", got, want)
+	}
+	if merged[0].Cmd != "2" {
+		t.Errorf("a was not replaced by the overlay")
+	}
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ramayac/multi-cmd/internal/executor"
+	"github.com/ramayac/multi-cmd/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Position locates a value in a config file. Line and Column are 1-based and
+// zero when unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+}
+
+// Problem is an issue found while loading or validating a config file
+type Problem struct {
+	Position
+	Message string
+}
+
+func (p Problem) Error() string {
+	return p.Position.String() + ": " + p.Message
+}
+
+func nodePosition(file string, node *yaml.Node) Position {
+	return Position{File: file, Line: node.Line, Column: node.Column}
+}
+
+func (l *loader) add(pos Position, format string, args ...any) {
+	l.problems = append(l.problems, Problem{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+var (
+	yamlLinePattern  = regexp.MustCompile(`^(?:yaml: )?line (\d+)(?::(\d+))?: (.*)$`)
+	yamlValuePattern = regexp.MustCompile("`([^`]*)`")
+)
+
+// addYAMLError records parser and decoder errors, which carry line numbers
+// in their text rather than as fields. For decoder errors, doc is searched
+// for the offending value to add its column.
+func (l *loader) addYAMLError(file string, err error, doc *yaml.Node) {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	for _, message := range messages {
+		pos := Position{File: file}
+		if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+			pos.Line, _ = strconv.Atoi(match[1])
+			pos.Column, _ = strconv.Atoi(match[2])
+			message = match[3]
+			if pos.Column == 0 && doc != nil {
+				if node := valueOnLine(doc, pos.Line, message); node != nil {
+					pos.Column = node.Column
+				}
+			}
+		}
+		l.add(pos, "%s", message)
+	}
+}
+
+// valueOnLine finds the value a decoder error on line is about: the scalar
+// quoted in message (yaml shortens long ones to 7 characters and "..."), or
+// else the first value starting on that line. Mapping keys are never values.
+func valueOnLine(doc *yaml.Node, line int, message string) *yaml.Node {
+	quoted, hasQuoted := "", false
+	if match := yamlValuePattern.FindStringSubmatch(message); match != nil {
+		quoted, hasQuoted = match[1], true
+	}
+
+	var first, exact *yaml.Node
+	var walk func(node *yaml.Node, isKey bool)
+	walk = func(node *yaml.Node, isKey bool) {
+		if exact != nil || node.Line > line {
+			return
+		}
+		if node.Line == line && !isKey {
+			if first == nil {
+				first = node
+			}
+			if hasQuoted && node.Kind == yaml.ScalarNode && matchesQuoted(node.Value, quoted) {
+				exact = node
+				return
+			}
+		}
+		for i, child := range node.Content {
+			walk(child, node.Kind == yaml.MappingNode && i%2 == 0)
+		}
+	}
+	walk(doc, false)
+
+	if exact != nil {
+		return exact
+	}
+	return first
+}
+
+func matchesQuoted(value, quoted string) bool {
+	if prefix, ok := strings.CutSuffix(quoted, "..."); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return value == quoted
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// checkKeys reports mapping keys that do not correspond to a field of typ
+func (l *loader) checkKeys(file string, node *yaml.Node, typ reflect.Type) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if reflect.PointerTo(typ).Implements(unmarshalerType) {
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(typ)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				l.add(nodePosition(file, key), "unknown key %q", key.Value)
+				continue
+			}
+			l.checkKeys(file, value, fieldType)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			l.checkKeys(file, item, typ.Elem())
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			l.checkKeys(file, node.Content[i], typ.Elem())
+		}
+	}
+}
+
+// yamlFields maps the YAML key of each struct field to the field type
+func yamlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkFile checks the commands and profiles defined in a single file.
+// Repeating a name across included files is an override, but repeating it
+// within one file is a mistake.
+func (l *loader) checkFile(file string, doc *yaml.Node) {
+	seen := make(map[string]Position)
+	for _, item := range sequenceItems(mappingValue(doc, "commands")) {
+		pos := nodePosition(file, item)
+		nameNode := mappingValue(item, "name")
+		if nameNode == nil || nameNode.Value == "" {
+			l.add(pos, "command without a name")
+			continue
+		}
+		name := nameNode.Value
+
+		if first, ok := seen[name]; ok {
+			l.add(pos, "duplicate command name %q (first defined at line %d)", name, first.Line)
+		}
+		seen[name] = pos
+		l.commands[name] = pos
+
+		hasCmd := strings.TrimSpace(scalarValue(mappingValue(item, "cmd"))) != ""
+		hasShell := strings.TrimSpace(scalarValue(mappingValue(item, "shell"))) != ""
+		switch {
+		case hasCmd && hasShell:
+			l.add(pos, "command %q sets both cmd and shell", name)
+		case !hasCmd && !hasShell:
+			l.add(pos, "command %q has an empty cmd", name)
+		}
+	}
+
+	seen = make(map[string]Position)
+	for _, item := range sequenceItems(mappingValue(doc, "profiles")) {
+		pos := nodePosition(file, item)
+		nameNode := mappingValue(item, "name")
+		if nameNode == nil || nameNode.Value == "" {
+			l.add(pos, "profile without a name")
+			continue
+		}
+		name := nameNode.Value
+
+		if first, ok := seen[name]; ok {
+			l.add(pos, "duplicate profile name %q (first defined at line %d)", name, first.Line)
+		}
+		seen[name] = pos
+		l.profiles[name] = pos
+	}
+}
+
+func scalarValue(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	return node.Value
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// checkMerged checks the final config after all includes are merged
+func (l *loader) checkMerged(configPath string, cfg *models.Config) {
+	if len(cfg.Commands) == 0 {
+		l.add(Position{File: configPath}, "no commands defined in config file")
+	}
+
+	if _, err := ParsePolicy(string(cfg.OnFailure)); err != nil {
+		l.add(Position{File: configPath}, "%v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, cmd := range cfg.Commands {
+		names[cmd.Name] = true
+	}
+
+	for _, cmd := range cfg.Commands {
+		if err := executor.CheckTemplates(cmd, cfg.Vars); err != nil {
+			l.add(l.commands[cmd.Name], "command %q: %v", cmd.Name, err)
+		}
+		l.checkCondition(cmd)
+		if cmd.Retries < 0 {
+			l.add(l.commands[cmd.Name], "command %q: retries must not be negative", cmd.Name)
+		}
+		if _, err := regexp.Compile(cmd.RetryOn); err != nil {
+			l.add(l.commands[cmd.Name], "command %q: invalid retry_on: %v", cmd.Name, err)
+		}
+
+		for _, dep := range cmd.DependsOn {
+			if !names[dep] {
+				l.add(l.commands[cmd.Name], "command %q depends on unknown command %q", cmd.Name, dep)
+			}
+		}
+	}
+
+	if cycle := executor.FindCycle(cfg.Commands); cycle != nil {
+		l.add(l.commands[cycle[0]], "depends_on cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	for _, profile := range cfg.Profiles {
+		for _, name := range profile.Commands {
+			if !names[name] {
+				l.add(l.profiles[profile.Name], "profile %q refers to unknown command %q", profile.Name, name)
+			}
+		}
+	}
+}
+
+// checkCondition reports malformed patterns in a command's when: block
+func (l *loader) checkCondition(cmd models.Command) {
+	if cmd.When == nil {
+		return
+	}
+
+	for _, pattern := range append([]string{cmd.When.Branch}, cmd.When.Glob...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			l.add(l.commands[cmd.Name], "command %q: invalid pattern %q in when", cmd.Name, pattern)
+		}
+	}
+}
+
+// checkBinaries reports commands whose binary is not on PATH. Commands given
+// by a path (e.g. ./gradlew) depend on the folder they run in and are not
+// checked.
+func (l *loader) checkBinaries(cfg *models.Config) {
+	for _, cmd := range cfg.Commands {
+		if cmd.Cmd == "" || strings.ContainsAny(cmd.Cmd, `/\`) {
+			continue
+		}
+		if _, err := exec.LookPath(cmd.Cmd); err != nil {
+			l.add(l.commands[cmd.Name], "command %q: %q not found in PATH", cmd.Name, cmd.Cmd)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeErrorPositions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"commands.yaml": `timeout: abc
+max_output: 12XB
+commands:
+  - name: a
+    cmd: "true"
+    retries: [1, 2]
+    timeout: 1234567890123x
+    bogus: true
+`,
+	})
+	path := filepath.Join(dir, "commands.yaml")
+
+	_, problems := Validate(path)
+	want := []string{
+		path + ":1:10",
+		path + ":2:13",
+		path + ":6:14",
+		path + ":7:14",
+		path + ":8:5",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, problem := range problems {
+		if got := problem.Position.String(); got != want[i] {
+			t.Errorf("problem %q at %s, want %s", problem.Message, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// skipReason evaluates the command's when: condition in a folder. It returns
+// an empty string when the command applies and otherwise why it does not.
+// env is the environment the command would run with (nil for the inherited
+// one).
+func skipReason(ctx context.Context, folder models.Folder, when *models.Condition, env []string) string {
+	if when == nil {
+		return ""
+	}
+
+	for _, name := range when.Exists {
+		if _, err := os.Stat(filepath.Join(folder.Path, name)); err != nil {
+			return fmt.Sprintf("%s does not exist", name)
+		}
+	}
+
+	for _, pattern := range when.Glob {
+		matches, err := filepath.Glob(filepath.Join(folder.Path, pattern))
+		if err != nil {
+			return fmt.Sprintf("invalid glob %q: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Sprintf("nothing matches %s", pattern)
+		}
+	}
+
+	for _, name := range when.Env {
+		if lookupEnv(env, name) == "" {
+			return fmt.Sprintf("$%s is not set", name)
+		}
+	}
+
+	if when.Branch != "" {
+		branch, err := currentBranch(ctx, folder)
+		if err != nil {
+			return err.Error()
+		}
+		if matched, _ := path.Match(when.Branch, branch); !matched {
+			return fmt.Sprintf("on branch %q, not %q", branch, when.Branch)
+		}
+	}
+
+	if when.Probe != "" {
+		name, args := shellCommand(when.Probe, "probe", nil)
+		probe := exec.CommandContext(ctx, name, args...)
+		probe.Dir = folder.Path
+		probe.Env = env
+		probe.WaitDelay = waitDelay
+		setProcessGroup(probe)
+
+		err := probe.Run()
+		if probe.Process != nil {
+			killProcessGroup(probe)
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return fmt.Sprintf("probe failed: %v", err)
+		}
+		if code := probe.ProcessState.ExitCode(); code != when.ProbeExit {
+			return fmt.Sprintf("probe exited with %d, not %d", code, when.ProbeExit)
+		}
+	}
+
+	return ""
+}
+
+// lookupEnv finds a variable in an exec.Cmd style environment, where later
+// entries win
+func lookupEnv(env []string, name string) string {
+	if env == nil {
+		return os.Getenv(name)
+	}
+
+	value := ""
+	for _, entry := range env {
+		if key, val, ok := strings.Cut(entry, "="); ok && key == name {
+			value = val
+		}
+	}
+	return value
+}
+
+// currentBranch prefers the branch read during a git-only scan
+func currentBranch(ctx context.Context, folder models.Folder) (string, error) {
+	if folder.Git != nil {
+		return folder.Git.Branch, nil
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--short", "-q", "HEAD")
+	cmd.Dir = folder.Path
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "(detached)", nil
+		}
+		return "", errors.New("not a git repository")
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
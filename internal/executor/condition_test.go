@@ -0,0 +1,138 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestSkipReason(t *testing.T) {
+	t.Setenv("MC_SET", "1")
+	t.Setenv("MC_EMPTY", "")
+
+	tests := []struct {
+		name string
+		when *models.Condition
+		git  *models.GitInfo
+		env  []string
+		want string
+	}{
+		{name: "no condition"},
+		{name: "exists", when: &models.Condition{Exists: []string{"package.json", "src"}}},
+		{name: "exists missing", when: &models.Condition{Exists: []string{"package.json", "go.mod"}}, want: "go.mod does not exist"},
+		{name: "glob", when: &models.Condition{Glob: []string{"src/*.go"}}},
+		{name: "glob no match", when: &models.Condition{Glob: []string{"*.py"}}, want: "nothing matches *.py"},
+		{name: "glob invalid", when: &models.Condition{Glob: []string{"[a"}}, want: `invalid glob "[a": syntax error in pattern`},
+		{name: "env inherited", when: &models.Condition{Env: []string{"MC_SET"}}},
+		{name: "env empty", when: &models.Condition{Env: []string{"MC_EMPTY"}}, want: "$MC_EMPTY is not set"},
+		{name: "env from command", when: &models.Condition{Env: []string{"MC_OWN"}}, env: []string{"MC_OWN=x"}},
+		{name: "env not in command env", when: &models.Condition{Env: []string{"MC_SET"}}, env: []string{"OTHER=x"}, want: "$MC_SET is not set"},
+		{name: "branch", when: &models.Condition{Branch: "release/*"}, git: &models.GitInfo{Branch: "release/1.2"}},
+		{name: "branch mismatch", when: &models.Condition{Branch: "release/*"}, git: &models.GitInfo{Branch: "main"}, want: `on branch "main", not "release/*"`},
+		{name: "branch outside git", when: &models.Condition{Branch: "main"}, want: "not a git repository"},
+		{name: "probe", when: &models.Condition{Probe: "test -d src"}},
+		{name: "probe exit", when: &models.Condition{Probe: "exit 1", ProbeExit: 1}},
+		{name: "probe exit mismatch", when: &models.Condition{Probe: "exit 2", ProbeExit: 1}, want: "probe exited with 2, not 1"},
+		{name: "probe sees env", when: &models.Condition{Probe: `[ "$MC_OWN" = x ]`}, env: []string{"MC_OWN=x"}},
+		{
+			name: "first failing condition",
+			when: &models.Condition{Exists: []string{"src"}, Glob: []string{"*.py"}, Probe: "exit 1"},
+			want: "nothing matches *.py",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := testFolders(t, "a")[0]
+			folder.Git = tt.git
+			writeTree(t, folder.Path, "package.json", "src/main.go")
+
+			if got := skipReason(context.Background(), folder, tt.when, tt.env); got != tt.want {
+				t.Errorf("skipReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	folder := testFolders(t, "a")[0]
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = folder.Path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init", "-q", "-b", "feature/x")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	if got, err := currentBranch(context.Background(), folder); got != "feature/x" || err != nil {
+		t.Errorf("currentBranch = %q, %v, want feature/x", got, err)
+	}
+
+	git("checkout", "-q", "--detach")
+	if got, err := currentBranch(context.Background(), folder); got != "(detached)" || err != nil {
+		t.Errorf("currentBranch when detached = %q, %v, want (detached)", got, err)
+	}
+}
+
+func TestExecuteCommandSkipped(t *testing.T) {
+	folder := testFolders(t, "a")[0]
+	marker := filepath.Join(folder.Path, "ran")
+	command := models.Command{
+		Name:  "npm",
+		Shell: "touch ran",
+		When:  &models.Condition{Exists: []string{"package.json"}},
+	}
+
+	result := ExecuteCommand(context.Background(), folder, command, Options{})
+	if result.Status != models.StatusSkipped || result.Error != "package.json does not exist" {
+		t.Errorf("status %q with error %q, want skipped because package.json does not exist", result.Status, result.Error)
+	}
+	if result.Success || result.ExitCode != -1 || !result.StartTime.IsZero() {
+		t.Errorf("skipped result has Success %v, ExitCode %d and StartTime %v", result.Success, result.ExitCode, result.StartTime)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("skipped command ran")
+	}
+}
+
+// writeTree creates empty files, and their directories, below dir
+func writeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProbeSharesTimeout(t *testing.T) {
+	folder := testFolders(t, "a")[0]
+	command := models.Command{
+		Name:    "slow",
+		Shell:   "sleep 0.4",
+		Timeout: 600 * time.Millisecond,
+		When:    &models.Condition{Probe: "sleep 0.4"},
+	}
+
+	result := ExecuteCommand(context.Background(), folder, command, Options{})
+	if result.Status != models.StatusTimedOut {
+		t.Errorf("status %q, want %q: the probe and the command together exceed the timeout", result.Status, models.StatusTimedOut)
+	}
+}
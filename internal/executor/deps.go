@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// dependencies maps each command to the positions of the commands it depends
+// on. Dependencies that are not in the list are ignored.
+func dependencies(commands []models.Command) [][]int {
+	index := make(map[string]int)
+	for i, cmd := range commands {
+		index[cmd.Name] = i
+	}
+
+	deps := make([][]int, len(commands))
+	for i, cmd := range commands {
+		for _, name := range cmd.DependsOn {
+			if j, ok := index[name]; ok {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps
+}
+
+// FindCycle returns the names along a depends_on cycle, starting and ending
+// with the same command, or nil when the commands form a DAG.
+func FindCycle(commands []models.Command) []string {
+	deps := dependencies(commands)
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(commands))
+	var stack []int
+
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range deps[i] {
+			switch state[j] {
+			case visiting:
+				var cycle []string
+				for k := len(stack) - 1; k >= 0; k-- {
+					cycle = append([]string{commands[stack[k]].Name}, cycle...)
+					if stack[k] == j {
+						break
+					}
+				}
+				return append(cycle, commands[j].Name)
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range commands {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// blockedReason explains why a job cannot run because of its dependencies,
+// or returns an empty string when every dependency succeeded.
+func blockedReason(results []models.ExecutionResult, deps []int) string {
+	var blocked []string
+	for _, i := range deps {
+		if !results[i].Success {
+			blocked = append(blocked, fmt.Sprintf("%s %s", results[i].CommandName, results[i].Status))
+		}
+	}
+	if len(blocked) == 0 {
+		return ""
+	}
+	sort.Strings(blocked)
+	return "dependency " + strings.Join(blocked, ", ")
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// commandEnv returns the inherited environment followed by the global and
+// the command's own variables. Values may refer to ${VAR}: global values are
+// expanded from the inherited environment, command values also see the
+// global ones. Later entries win, as with exec.Cmd.Env.
+func commandEnv(command models.Command, global map[string]string) []string {
+	if len(global) == 0 && len(command.Env) == 0 {
+		return nil
+	}
+
+	env := os.Environ()
+	globalValues := make(map[string]string)
+	for _, name := range sortedKeys(global) {
+		globalValues[name] = os.ExpandEnv(global[name])
+		env = append(env, name+"="+globalValues[name])
+	}
+
+	lookup := func(name string) string {
+		if value, ok := globalValues[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}
+	for _, name := range sortedKeys(command.Env) {
+		env = append(env, name+"="+os.Expand(command.Env[name], lookup))
+	}
+	return env
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// workdir resolves the command's working directory against the folder. A
+// missing directory is reported here because exec reports it as if the
+// program was not found.
+func workdir(folder models.Folder, command models.Command) (string, error) {
+	if command.Workdir == "" {
+		return folder.Path, nil
+	}
+
+	dir := command.Workdir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(folder.Path, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("workdir %s: %w", command.Workdir, errors.Unwrap(err))
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("workdir %s: not a directory", command.Workdir)
+	}
+	return dir, nil
+}
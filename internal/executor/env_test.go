@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestCommandEnv(t *testing.T) {
+	t.Setenv("MC_BASE", "/base")
+	t.Setenv("MC_SHARED", "inherited")
+
+	tests := []struct {
+		name    string
+		global  map[string]string
+		command map[string]string
+		want    map[string]string
+	}{
+		{
+			name:   "global from inherited",
+			global: map[string]string{"MC_A": "${MC_BASE}/a"},
+			want:   map[string]string{"MC_A": "/base/a"},
+		},
+		{
+			name:    "command sees global",
+			global:  map[string]string{"MC_A": "${MC_BASE}/a"},
+			command: map[string]string{"MC_B": "${MC_A}/b"},
+			want:    map[string]string{"MC_A": "/base/a", "MC_B": "/base/a/b"},
+		},
+		{
+			name:    "command overrides global",
+			global:  map[string]string{"MC_SHARED": "global"},
+			command: map[string]string{"MC_SHARED": "command, was ${MC_SHARED}"},
+			want:    map[string]string{"MC_SHARED": "command, was global"},
+		},
+		{
+			// Global values only see the inherited environment, and
+			// command values only the global ones, not each other
+			name:    "no chaining within a level",
+			global:  map[string]string{"MC_A": "a", "MC_B": "[${MC_A}]"},
+			command: map[string]string{"MC_C": "c", "MC_D": "[${MC_C}]"},
+			want:    map[string]string{"MC_B": "[]", "MC_D": "[]"},
+		},
+		{
+			name:    "bare names and special parameters",
+			command: map[string]string{"MC_A": "$MC_BASE/x", "MC_B": "a$$b", "MC_C": "cost $5"},
+			want:    map[string]string{"MC_A": "/base/x", "MC_B": "ab", "MC_C": "cost "},
+		},
+		{
+			name:    "unset",
+			command: map[string]string{"MC_A": "${MC_UNSET}x"},
+			want:    map[string]string{"MC_A": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := commandEnv(models.Command{Env: tt.command}, tt.global)
+			for name, want := range tt.want {
+				if got := lookupEnv(env, name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := lookupEnv(env, "MC_BASE"); got != "/base" {
+				t.Errorf("inherited MC_BASE = %q", got)
+			}
+		})
+	}
+}
+
+func TestCommandEnvInherits(t *testing.T) {
+	if env := commandEnv(models.Command{}, nil); env != nil {
+		t.Errorf("commandEnv without env = %d entries, want nil to inherit", len(env))
+	}
+}
+
+func TestWorkdir(t *testing.T) {
+	folder := models.Folder{Name: "a", Path: t.TempDir()}
+	if err := os.Mkdir(filepath.Join(folder.Path, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(folder.Path, "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		workdir string
+		want    string
+		err     string
+	}{
+		{workdir: "", want: folder.Path},
+		{workdir: "web", want: filepath.Join(folder.Path, "web")},
+		{workdir: "missing", err: "workdir missing: no such file or directory"},
+		{workdir: "file", err: "workdir file: not a directory"},
+	}
+
+	for _, tt := range tests {
+		dir, err := workdir(folder, models.Command{Workdir: tt.workdir})
+		switch {
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("workdir(%q) error = %v, want %q", tt.workdir, err, tt.err)
+		case tt.err == "" && (err != nil || dir != tt.want):
+			t.Errorf("workdir(%q) = %q, %v, want %q", tt.workdir, dir, err, tt.want)
+		}
+	}
+}
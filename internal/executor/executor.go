@@ -2,73 +2,526 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ramayac/multi-cmd/internal/models"
 )
 
-// Execute runs the selected commands on the selected folders
-func Execute(folders []models.Folder, commands []models.Command) []models.ExecutionResult {
-	var results []models.ExecutionResult
+// waitDelay bounds how long a run waits, once its process has exited and
+// its process group was killed, for output from processes that left the
+// group and still hold the pipes open. It is also how long Wait waits for a
+// cancelled process to die before killing it directly.
+const waitDelay = 2 * time.Second
 
+// Options controls how Execute schedules commands
+type Options struct {
+	// Concurrency is the number of commands run at the same time.
+	// Values below 1 default to the number of CPUs.
+	Concurrency int
+
+	// Timeout applies to commands that do not set their own timeout.
+	// Zero means no deadline.
+	Timeout time.Duration
+
+	// Vars are the config-level template vars; a command's own vars take
+	// precedence.
+	Vars map[string]string
+
+	// OnFailure decides what happens to commands that have not started
+	// when one fails or times out. Empty means continue.
+	OnFailure models.FailurePolicy
+
+	// MaxOutput caps each output stream of commands that do not set their
+	// own limit. Zero means DefaultMaxOutput.
+	MaxOutput models.ByteSize
+
+	// SpillDir, when set, receives the full output of truncated streams.
+	// Execute uses a new timestamped subdirectory for every batch.
+	SpillDir string
+
+	// Env is the config-level environment, applied before a command's own
+	// env.
+	Env map[string]string
+
+	// OnResult, when set, is called as each command finishes, in completion
+	// order. Calls are serialized so the callback needs no locking.
+	OnResult func(models.ExecutionResult)
+}
+
+// OptionsFromConfig builds execution options from the config file settings
+func OptionsFromConfig(cfg *models.Config) Options {
+	return Options{
+		Concurrency: cfg.Concurrency,
+		Timeout:     cfg.Timeout,
+		OnFailure:   cfg.OnFailure,
+		MaxOutput:   cfg.MaxOutput,
+		SpillDir:    cfg.SpillDir,
+		Vars:        cfg.Vars,
+		Env:         cfg.Env,
+	}
+}
+
+func (o Options) timeoutFor(command models.Command) time.Duration {
+	if command.Timeout > 0 {
+		return command.Timeout
+	}
+	return o.Timeout
+}
+
+func (o Options) workers(jobs int) int {
+	n := o.Concurrency
+	if n < 1 {
+		n = runtime.NumCPU()
+	}
+	if n > jobs {
+		n = jobs
+	}
+	return n
+}
+
+type job struct {
+	index   int
+	folder  models.Folder
+	command models.Command
+	// deps and dependents are job indices within the same folder
+	deps       []int
+	dependents []int
+}
+
+// Execute runs the selected commands on the selected folders using a pool of
+// workers. Within a folder a command starts only after the commands it
+// depends on have succeeded; when one of them did not, the command is
+// recorded as skipped. Folders are independent of each other unless the
+// OnFailure policy stops the whole batch. Results are
+// returned in folder/command order regardless of the order in which the
+// commands finish. Cancelling ctx kills running commands and marks the rest
+// of the queue as cancelled without running it.
+func Execute(ctx context.Context, folders []models.Folder, commands []models.Command, opts Options) []models.ExecutionResult {
+	deps := dependencies(commands)
+	cycle := FindCycle(commands)
+
+	if opts.SpillDir != "" {
+		opts.SpillDir = runSpillDir(opts.SpillDir, time.Now())
+		// Only kept when something was spilled
+		defer os.Remove(opts.SpillDir)
+	}
+
+	var jobs []job
 	for _, folder := range folders {
 		if !folder.Selected {
 			continue
 		}
 
+		base := len(jobs)
 		for _, cmd := range commands {
-			result := ExecuteCommand(folder, cmd)
-			results = append(results, result)
+			jobs = append(jobs, job{index: len(jobs), folder: folder, command: cmd})
+		}
+		for i := range commands {
+			for _, dep := range deps[i] {
+				jobs[base+i].deps = append(jobs[base+i].deps, base+dep)
+				jobs[base+dep].dependents = append(jobs[base+dep].dependents, base+i)
+			}
+		}
+	}
+
+	results := make([]models.ExecutionResult, len(jobs))
+	queue := make(chan job)
+	done := make(chan int)
+
+	var notifyMu sync.Mutex
+	notify := func(result models.ExecutionResult) {
+		if opts.OnResult != nil {
+			notifyMu.Lock()
+			opts.OnResult(result)
+			notifyMu.Unlock()
+		}
+	}
+
+	if cycle != nil {
+		for _, j := range jobs {
+			results[j.index] = notRun(j, models.StatusFailed, "dependency cycle: "+strings.Join(cycle, " -> "), opts.Vars)
+			notify(results[j.index])
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers(len(jobs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				results[j.index] = ExecuteCommand(ctx, j.folder, j.command, opts)
+				notify(results[j.index])
+				done <- j.index
+			}
+		}()
+	}
+
+	// ready is kept sorted so that, worker count permitting, jobs start in
+	// folder/command order
+	var ready []int
+	pending := make([]int, len(jobs))
+	for _, j := range jobs {
+		pending[j.index] = len(j.deps)
+		if pending[j.index] == 0 {
+			ready = append(ready, j.index)
 		}
 	}
 
+	// stopped holds why commands are no longer started, per folder path,
+	// with the empty path standing for the whole batch
+	stopped := make(map[string]string)
+	stopReason := func(j job) string {
+		if reason, ok := stopped[""]; ok {
+			return reason
+		}
+		return stopped[j.folder.Path]
+	}
+
+	remaining := len(jobs)
+	var finish func(index int)
+	finish = func(index int) {
+		remaining--
+		if result := results[index]; triggersPolicy(result, jobs[index].command) {
+			reason := fmt.Sprintf("%s %s in %s", result.CommandName, result.Status, result.FolderName)
+			switch opts.OnFailure {
+			case models.PolicyStopAll:
+				if _, ok := stopped[""]; !ok {
+					stopped[""] = "batch stopped: " + reason
+				}
+			case models.PolicyStopFolder:
+				if _, ok := stopped[result.FolderPath]; !ok {
+					stopped[result.FolderPath] = "folder stopped: " + reason
+				}
+			}
+		}
+
+		for _, d := range jobs[index].dependents {
+			pending[d]--
+			if pending[d] > 0 {
+				continue
+			}
+
+			// Once cancelled, dependents still go through ExecuteCommand
+			// so that they are reported as cancelled rather than skipped
+			if reason := blockedReason(results, jobs[d].deps); reason != "" && ctx.Err() == nil {
+				results[d] = notRun(jobs[d], models.StatusSkipped, reason, opts.Vars)
+				notify(results[d])
+				finish(d)
+				continue
+			}
+			ready = insertSorted(ready, d)
+		}
+	}
+
+	for remaining > 0 {
+		if len(ready) > 0 {
+			if reason := stopReason(jobs[ready[0]]); reason != "" {
+				index := ready[0]
+				ready = ready[1:]
+				results[index] = notRun(jobs[index], models.StatusSkipped, reason, opts.Vars)
+				notify(results[index])
+				finish(index)
+				continue
+			}
+		}
+
+		var send chan job
+		var next job
+		if len(ready) > 0 {
+			send = queue
+			next = jobs[ready[0]]
+		}
+
+		select {
+		case send <- next:
+			ready = ready[1:]
+		case index := <-done:
+			finish(index)
+		}
+	}
+	close(queue)
+	wg.Wait()
+
 	return results
 }
 
-func ExecuteCommand(folder models.Folder, command models.Command) models.ExecutionResult {
-	// Build the full command string for display
-	cmdString := command.Cmd
-	if len(command.Args) > 0 {
-		for _, arg := range command.Args {
-			cmdString += " " + arg
-		}
+// triggersPolicy reports whether a result counts as a failure for the
+// OnFailure policy. Skipped and cancelled commands did not fail.
+func triggersPolicy(result models.ExecutionResult, command models.Command) bool {
+	if command.ContinueOnError {
+		return false
+	}
+	return result.Status == models.StatusFailed || result.Status == models.StatusTimedOut
+}
+
+// notRun records a job that was never started. The command is shown
+// expanded as it would have run, or as written when its templates fail.
+func notRun(j job, status models.ResultStatus, reason string, vars map[string]string) models.ExecutionResult {
+	_, _, display, err := commandLine(j.folder, j.command, vars)
+	if err != nil {
+		display = joinCommand(j.command.Cmd+j.command.Shell, j.command.Args)
+	}
+	return models.ExecutionResult{
+		FolderName:      j.folder.Name,
+		FolderPath:      j.folder.Path,
+		CommandName:     j.command.Name,
+		CommandExecuted: display,
+		Status:          status,
+		Error:           reason,
+		ExitCode:        -1,
 	}
+}
+
+func insertSorted(list []int, value int) []int {
+	i := sort.SearchInts(list, value)
+	list = append(list, 0)
+	copy(list[i+1:], list[i:])
+	list[i] = value
+	return list
+}
 
+// ExecuteCommand runs a single command in the given folder. When a timeout
+// applies, the whole process group is killed once the deadline passes.
+func ExecuteCommand(ctx context.Context, folder models.Folder, command models.Command, opts Options) models.ExecutionResult {
 	result := models.ExecutionResult{
-		FolderName:      folder.Name,
-		FolderPath:      folder.Path,
-		CommandName:     command.Name,
-		CommandExecuted: cmdString,
+		FolderName:  folder.Name,
+		FolderPath:  folder.Path,
+		CommandName: command.Name,
+		ExitCode:    -1,
 	}
 
-	cmd := exec.Command(command.Cmd, command.Args...)
-	cmd.Dir = folder.Path
+	name, args, display, err := commandLine(folder, command, opts.Vars)
+	if err != nil {
+		result.CommandExecuted = joinCommand(command.Cmd+command.Shell, command.Args)
+		result.Status = models.StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.CommandExecuted = display
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	if ctx.Err() != nil {
+		result.Status = models.StatusCancelled
+		result.Error = "not run: batch was cancelled"
+		return result
+	}
 
-	err := cmd.Run()
-	result.Output = stdout.String()
+	// The when: probe and the first attempt share one deadline
+	timeout := opts.timeoutFor(command)
+	env := commandEnv(command, opts.Env)
+	firstCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	reason := skipReason(firstCtx, folder, command.When, env)
+	if err := firstCtx.Err(); err != nil {
+		setStatus(&result, firstCtx, err, timeout)
+		return result
+	}
+	if reason != "" {
+		result.Status = models.StatusSkipped
+		result.Error = reason
+		return result
+	}
 
+	dir, err := workdir(folder, command)
 	if err != nil {
-		result.Success = false
-		result.Error = fmt.Sprintf("%v: %s", err, stderr.String())
-	} else {
-		result.Success = true
+		result.Status = models.StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	retryOn, err := retryPattern(command)
+	if err != nil {
+		result.Status = models.StatusFailed
+		result.Error = err.Error()
+		return result
 	}
 
+	backoff := command.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	result.StartTime = time.Now()
+	for attempt := 1; ; attempt++ {
+		// Retries get the full timeout again
+		parent, attemptTimeout := ctx, timeout
+		if attempt == 1 {
+			parent, attemptTimeout = firstCtx, 0
+		}
+		attemptCtx, cancel := withTimeout(parent, attemptTimeout)
+		cmd := exec.CommandContext(attemptCtx, name, args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		// Only matters once ctx is done: output goes through run's pipes
+		cmd.WaitDelay = waitDelay
+		setProcessGroup(cmd)
+		runAttempt(attemptCtx, cmd, command, opts, timeout, &result)
+		cancel()
+
+		result.Attempts = attempt
+		if result.Success {
+			break
+		}
+		result.AttemptErrors = append(result.AttemptErrors, attemptError(result))
+		if attempt > command.Retries || !retryable(result, retryOn) {
+			break
+		}
+		if !sleep(ctx, backoff) {
+			result.Status = models.StatusCancelled
+			result.Error = "cancelled while waiting to retry"
+			break
+		}
+		backoff *= 2
+	}
+	result.Duration = time.Since(result.StartTime)
+
 	return result
 }
 
+// runAttempt runs cmd once and records its output and outcome in result
+func runAttempt(ctx context.Context, cmd *exec.Cmd, command models.Command, opts Options, timeout time.Duration, result *models.ExecutionResult) {
+	streams := []string{"stdout", "stderr"}
+	if command.CombinedOutput {
+		streams = append(streams, "combined")
+	}
+	capture, err := newOutputCapture(opts.maxOutputFor(command), opts.SpillDir, *result, streams...)
+	if err != nil {
+		result.Status = models.StatusFailed
+		result.Error = err.Error()
+		return
+	}
+
+	stdout, stderr := capture.streams["stdout"], capture.streams["stderr"]
+	var outWriter, errWriter io.Writer = stdout, stderr
+	if combined := capture.streams["combined"]; combined != nil {
+		outWriter = io.MultiWriter(stdout, combined)
+		errWriter = io.MultiWriter(stderr, combined)
+	}
+
+	err = run(cmd, outWriter, errWriter)
+	result.OutputFiles = capture.close()
+	result.Truncated = capture.truncated()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Combined = ""
+	if combined := capture.streams["combined"]; combined != nil {
+		result.Combined = combined.String()
+	}
+
+	result.ExitCode = -1
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	setStatus(result, ctx, err, timeout)
+}
+
+// run runs cmd with its output copied into stdout and stderr through pipes
+// of its own, so that Wait returns as soon as the process exits rather than
+// when every process holding the pipes has. Anything the command left
+// running in its process group is then killed, and output that is still
+// held open after waitDelay is cut off.
+func run(cmd *exec.Cmd, stdout, stderr io.Writer) error {
+	outRead, outWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	errRead, errWrite, err := os.Pipe()
+	if err != nil {
+		outRead.Close()
+		outWrite.Close()
+		return err
+	}
+	cmd.Stdout, cmd.Stderr = outWrite, errWrite
+
+	err = cmd.Start()
+	outWrite.Close()
+	errWrite.Close()
+	if err != nil {
+		outRead.Close()
+		errRead.Close()
+		return err
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); io.Copy(stdout, outRead) }()
+		go func() { defer wg.Done(); io.Copy(stderr, errRead) }()
+		wg.Wait()
+		close(copied)
+	}()
+
+	err = cmd.Wait()
+	killProcessGroup(cmd)
+
+	timer := time.NewTimer(waitDelay)
+	defer timer.Stop()
+	select {
+	case <-copied:
+	case <-timer.C:
+	}
+	// Closing the read ends stops copies that are still blocked
+	outRead.Close()
+	errRead.Close()
+	<-copied
+	return err
+}
+
+// setStatus classifies how a run ended. ctx is the context it ran under,
+// carrying the timeout.
+func setStatus(result *models.ExecutionResult, ctx context.Context, err error, timeout time.Duration) {
+	result.Success = false
+	result.Error = ""
+
+	switch {
+	case err == nil:
+		result.Success = true
+		result.Status = models.StatusSuccess
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Status = models.StatusTimedOut
+		result.Error = fmt.Sprintf("killed after %s", timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		result.Status = models.StatusCancelled
+		result.Error = "killed when the batch was cancelled"
+	default:
+		result.Status = models.StatusFailed
+		result.Error = err.Error()
+	}
+}
+
+// withTimeout is context.WithTimeout, except that zero means no deadline
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // WriteResults writes the execution results to a file
 func WriteResults(results []models.ExecutionResult, outputPath string) error {
 	var buf bytes.Buffer
 
 	buf.WriteString("# Folder Command Results\n\n")
 
+	if cancelled := countStatus(results, models.StatusCancelled); cancelled > 0 {
+		buf.WriteString(fmt.Sprintf("> **Run cancelled:** %d of %d commands did not complete.\n\n", cancelled, len(results)))
+	}
+	if skipped := countStatus(results, models.StatusSkipped); skipped > 0 {
+		buf.WriteString(fmt.Sprintf("> **Skipped:** %d of %d commands were not run; each one states why.\n\n", skipped, len(results)))
+	}
+
 	currentFolder := ""
 	for _, result := range results {
 		if result.FolderName != currentFolder {
@@ -79,18 +532,95 @@ func WriteResults(results []models.ExecutionResult, outputPath string) error {
 
 		buf.WriteString(fmt.Sprintf("### %s\n", result.CommandName))
 		buf.WriteString(fmt.Sprintf("**Command:** `%s`\n\n", result.CommandExecuted))
+		exitCode, started, duration := timingFields(result)
+		buf.WriteString(fmt.Sprintf("**Exit code:** %s | **Started:** %s | **Duration:** %s\n\n", exitCode, started, duration))
 
-		if result.Success {
-			buf.WriteString("```\n")
-			buf.WriteString(result.Output)
-			if len(result.Output) > 0 && result.Output[len(result.Output)-1] != '\n' {
+		if result.Attempts > 1 {
+			buf.WriteString(fmt.Sprintf("**Attempts:** %d\n\n", result.Attempts))
+			for i, attemptErr := range result.AttemptErrors {
+				buf.WriteString(fmt.Sprintf("- Attempt %d: %s\n", i+1, attemptErr))
+			}
+			if len(result.AttemptErrors) > 0 {
 				buf.WriteString("\n")
 			}
-			buf.WriteString("```\n\n")
-		} else {
+		}
+
+		if files := FormatOutputFiles(result); files != "" {
+			buf.WriteString(fmt.Sprintf("**Full output:** %s\n\n", files))
+		}
+
+		if result.Status == models.StatusTimedOut {
+			buf.WriteString(fmt.Sprintf("**Timed out:** %s\n\n", result.Error))
+		} else if result.Status == models.StatusCancelled {
+			buf.WriteString(fmt.Sprintf("**Cancelled:** %s\n\n", result.Error))
+		} else if result.Status == models.StatusSkipped {
+			buf.WriteString(fmt.Sprintf("**Skipped:** %s\n\n", result.Error))
+		} else if !result.Success {
 			buf.WriteString(fmt.Sprintf("**Error:** %s\n\n", result.Error))
 		}
+
+		if result.Combined != "" {
+			writeCodeBlock(&buf, "**Combined output:**\n", result.Combined)
+			continue
+		}
+		if result.Stdout != "" || result.Success {
+			writeCodeBlock(&buf, "", result.Stdout)
+		}
+		if result.Stderr != "" {
+			writeCodeBlock(&buf, "**Stderr:**\n", result.Stderr)
+		}
 	}
 
 	return os.WriteFile(outputPath, buf.Bytes(), 0644)
 }
+
+func writeCodeBlock(buf *bytes.Buffer, label, text string) {
+	buf.WriteString(label)
+	buf.WriteString("```\n")
+	buf.WriteString(text)
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("```\n\n")
+}
+
+// FormatOutputFiles lists the files holding the full output of truncated
+// streams, or returns an empty string when there are none
+func FormatOutputFiles(result models.ExecutionResult) string {
+	var files []string
+	for _, stream := range []string{"stdout", "stderr", "combined"} {
+		if path, ok := result.OutputFiles[stream]; ok {
+			files = append(files, fmt.Sprintf("%s `%s`", stream, path))
+		}
+	}
+	return strings.Join(files, ", ")
+}
+
+// FormatTiming summarizes the exit code, start time and duration of a result
+func FormatTiming(result models.ExecutionResult) string {
+	exitCode, started, duration := timingFields(result)
+	return fmt.Sprintf("Exit code: %s | Started: %s | Duration: %s", exitCode, started, duration)
+}
+
+func timingFields(result models.ExecutionResult) (exitCode, started, duration string) {
+	exitCode = "n/a"
+	if result.ExitCode >= 0 {
+		exitCode = fmt.Sprintf("%d", result.ExitCode)
+	}
+
+	if result.StartTime.IsZero() {
+		return exitCode, "not started", "n/a"
+	}
+
+	return exitCode, result.StartTime.Format("2006-01-02 15:04:05"), result.Duration.Round(time.Millisecond).String()
+}
+
+func countStatus(results []models.ExecutionResult, status models.ResultStatus) int {
+	count := 0
+	for _, result := range results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,151 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func testFolders(t *testing.T, names ...string) []models.Folder {
+	t.Helper()
+	var folders []models.Folder
+	for _, name := range names {
+		folders = append(folders, models.Folder{Name: name, Path: t.TempDir(), Selected: true})
+	}
+	return folders
+}
+
+func statuses(results []models.ExecutionResult) []string {
+	var out []string
+	for _, result := range results {
+		out = append(out, result.FolderName+"/"+result.CommandName+": "+string(result.Status))
+	}
+	return out
+}
+
+func TestExecuteDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		folders  []string
+		commands []models.Command
+		want     []string
+	}{
+		{
+			name:    "dependency failed",
+			folders: []string{"a"},
+			commands: []models.Command{
+				{Name: "build", Shell: "exit 1"},
+				{Name: "test", Shell: "true", DependsOn: []string{"build"}},
+				{Name: "deploy", Shell: "true", DependsOn: []string{"test"}},
+				{Name: "lint", Shell: "true"},
+			},
+			want: []string{
+				"a/build: failed",
+				"a/test: skipped",
+				"a/deploy: skipped",
+				"a/lint: success",
+			},
+		},
+		{
+			name:    "dependency succeeded",
+			folders: []string{"a"},
+			commands: []models.Command{
+				{Name: "test", Shell: "true", DependsOn: []string{"build"}},
+				{Name: "build", Shell: "true"},
+			},
+			want: []string{
+				"a/test: success",
+				"a/build: success",
+			},
+		},
+		{
+			name:    "dependency cycle",
+			folders: []string{"a"},
+			commands: []models.Command{
+				{Name: "x", Shell: "true", DependsOn: []string{"y"}},
+				{Name: "y", Shell: "true", DependsOn: []string{"x"}},
+			},
+			want: []string{
+				"a/x: failed",
+				"a/y: failed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folders := testFolders(t, tt.folders...)
+
+			results := Execute(context.Background(), folders, tt.commands, Options{Concurrency: 1})
+
+			got := statuses(results)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExecuteSkipReasons(t *testing.T) {
+	folders := testFolders(t, "a")
+	commands := []models.Command{
+		{Name: "build", Shell: "exit 1"},
+		{Name: "test", Shell: "echo {{.Folder.Name}}", Template: true, DependsOn: []string{"build"}},
+	}
+
+	results := Execute(context.Background(), folders, commands, Options{})
+	test := results[1]
+	if test.Error != "dependency build failed" {
+		t.Errorf("Error = %q, want %q", test.Error, "dependency build failed")
+	}
+	if test.CommandExecuted != "echo a" {
+		t.Errorf("CommandExecuted = %q, want the expanded %q", test.CommandExecuted, "echo a")
+	}
+	if test.ExitCode != -1 || !test.StartTime.IsZero() {
+		t.Errorf("skipped command has ExitCode %d and StartTime %v", test.ExitCode, test.StartTime)
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []models.Command
+		want     string
+	}{
+		{
+			name: "none",
+			commands: []models.Command{
+				{Name: "a"},
+				{Name: "b", DependsOn: []string{"a", "missing"}},
+			},
+		},
+		{
+			name: "self",
+			commands: []models.Command{
+				{Name: "a", DependsOn: []string{"a"}},
+			},
+			want: "a -> a",
+		},
+		{
+			name: "indirect",
+			commands: []models.Command{
+				{Name: "a", DependsOn: []string{"c"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"b"}},
+			},
+			want: "a -> c -> b -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(FindCycle(tt.commands), " -> "); got != tt.want {
+				t.Errorf("FindCycle = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
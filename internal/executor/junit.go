@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// JUnit XML layout: one testsuite per folder, one testcase per command.
+// Failed commands become failures, timeouts become errors and commands that
+// never completed are reported as skipped.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+
+	duration time.Duration
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+type junitWriter struct {
+	path string
+}
+
+func (w *junitWriter) Add(models.ExecutionResult) error { return nil }
+
+func (w *junitWriter) Close(results []models.ExecutionResult) error {
+	return WriteJUnit(results, w.path)
+}
+
+// WriteJUnit writes the execution results as a JUnit XML report
+func WriteJUnit(results []models.ExecutionResult, outputPath string) error {
+	report := junitTestSuites{Name: "multi-cmd"}
+	var total time.Duration
+
+	suiteIndex := make(map[string]int)
+	for _, result := range results {
+		i, ok := suiteIndex[result.FolderPath]
+		if !ok {
+			i = len(report.Suites)
+			suiteIndex[result.FolderPath] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.FolderName})
+			if !result.StartTime.IsZero() {
+				report.Suites[i].Timestamp = result.StartTime.Format("2006-01-02T15:04:05")
+			}
+		}
+		suite := &report.Suites[i]
+
+		testCase := junitTestCase{
+			Name:      result.CommandName,
+			Classname: result.FolderName,
+			Time:      junitSeconds(result.Duration),
+			SystemOut: result.Stdout,
+			SystemErr: result.Stderr,
+		}
+
+		message := fmt.Sprintf("exit code %d: %s", result.ExitCode, result.Error)
+		switch {
+		case result.Success:
+		case result.Status == models.StatusTimedOut:
+			testCase.Error = &junitProblem{Message: message, Type: string(result.Status), Body: result.Stderr}
+			suite.Errors++
+		case result.Status == models.StatusCancelled, result.Status == models.StatusSkipped:
+			testCase.Skipped = &junitProblem{Message: result.Error}
+			suite.Skipped++
+		default:
+			testCase.Failure = &junitProblem{Message: message, Type: string(result.Status), Body: result.Stderr}
+			suite.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		suite.duration += result.Duration
+		total += result.Duration
+	}
+
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		suite.Time = junitSeconds(suite.duration)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+	report.Time = junitSeconds(total)
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputPath, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
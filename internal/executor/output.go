@@ -0,0 +1,242 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// DefaultMaxOutput is the per-stream output limit when neither the command
+// nor the config sets one
+const DefaultMaxOutput = 10 * models.ByteSize(1<<20)
+
+// cappedBuffer keeps the first and last limit/2 bytes written to it and
+// counts what was dropped in between. When a spill file is set, everything
+// is also written there. It can be written to from the stdout and stderr
+// copying goroutines at the same time.
+type cappedBuffer struct {
+	mu    sync.Mutex
+	limit int64
+	head  bytes.Buffer
+	tail  ring
+	total int64
+
+	spill    *os.File
+	spillErr error
+}
+
+func newCappedBuffer(limit models.ByteSize) *cappedBuffer {
+	b := &cappedBuffer{limit: int64(limit)}
+	if limit >= 0 {
+		b.tail.size = int(limit - limit/2)
+	}
+	return b
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.total += int64(len(p))
+	if b.spill != nil && b.spillErr == nil {
+		_, b.spillErr = b.spill.Write(p)
+	}
+
+	rest := p
+	if b.limit < 0 {
+		b.head.Write(rest)
+		return len(p), nil
+	}
+	if room := b.limit/2 - int64(b.head.Len()); room > 0 {
+		n := min(room, int64(len(rest)))
+		b.head.Write(rest[:n])
+		rest = rest[n:]
+	}
+	b.tail.write(rest)
+	return len(p), nil
+}
+
+// truncated is the number of bytes dropped between the head and the tail
+func (b *cappedBuffer) truncated() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total - int64(b.head.Len()) - int64(b.tail.len())
+}
+
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := b.total - int64(b.head.Len()) - int64(b.tail.len())
+	if dropped == 0 {
+		return b.head.String() + string(b.tail.bytes())
+	}
+
+	// Start the tail on a fresh line when it has one
+	tail := b.tail.bytes()
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		dropped += int64(i + 1)
+		tail = tail[i+1:]
+	}
+
+	head := b.head.String()
+	if !strings.HasSuffix(head, "\n") {
+		head += "\n"
+	}
+	marker := fmt.Sprintf("[... %d bytes truncated ...]\n", dropped)
+	return strings.ToValidUTF8(head, "") + marker + strings.ToValidUTF8(string(tail), "")
+}
+
+// ring keeps the last size bytes written to it. buf only grows as bytes
+// arrive, so a large limit costs nothing for commands with little output.
+type ring struct {
+	size int
+	buf  []byte
+	next int
+}
+
+func (r *ring) write(p []byte) {
+	size := r.size
+	if size == 0 || len(p) == 0 {
+		return
+	}
+	if len(p) >= size {
+		r.buf = append(r.buf[:0], p[len(p)-size:]...)
+		r.next = 0
+		return
+	}
+
+	if len(r.buf) < size {
+		// Still filling up
+		n := min(size-len(r.buf), len(p))
+		r.buf = append(r.buf, p[:n]...)
+		p = p[n:]
+	}
+	for len(p) > 0 {
+		n := copy(r.buf[r.next:], p)
+		p = p[n:]
+		r.next = (r.next + n) % size
+	}
+}
+
+func (r *ring) len() int {
+	return len(r.buf)
+}
+
+// bytes returns the contents oldest first
+func (r *ring) bytes() []byte {
+	out := make([]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+// outputCapture holds the buffers for one attempt of a command
+type outputCapture struct {
+	streams map[string]*cappedBuffer
+	files   map[string]string
+}
+
+// newOutputCapture creates a buffer per stream, each spilling to a file in
+// spillDir when one is given.
+func newOutputCapture(limit models.ByteSize, spillDir string, result models.ExecutionResult, streams ...string) (*outputCapture, error) {
+	c := &outputCapture{
+		streams: make(map[string]*cappedBuffer),
+		files:   make(map[string]string),
+	}
+
+	for _, stream := range streams {
+		buf := newCappedBuffer(limit)
+		c.streams[stream] = buf
+		if spillDir == "" || limit < 0 {
+			continue
+		}
+
+		if err := os.MkdirAll(spillDir, 0755); err != nil {
+			c.close()
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+		path := filepath.Join(spillDir, spillName(result, stream))
+		file, err := os.Create(path)
+		if err != nil {
+			c.close()
+			return nil, fmt.Errorf("failed to create spill file: %w", err)
+		}
+		buf.spill = file
+		c.files[stream] = path
+	}
+	return c, nil
+}
+
+// close closes the spill files and removes those of streams that fit in the
+// limit, returning the files that were kept
+func (c *outputCapture) close() map[string]string {
+	var kept map[string]string
+	for stream, buf := range c.streams {
+		if buf.spill == nil {
+			continue
+		}
+
+		err := buf.spill.Close()
+		if buf.truncated() == 0 || buf.spillErr != nil || err != nil {
+			os.Remove(c.files[stream])
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string]string)
+		}
+		kept[stream] = c.files[stream]
+	}
+	return kept
+}
+
+func (c *outputCapture) truncated() bool {
+	for _, buf := range c.streams {
+		if buf.truncated() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// spillName builds a file name such as api__Git_Status-1a2b3c4d.stdout.log.
+// The hash of the folder path and command name keeps names apart that only
+// differ in characters replaced by _.
+func spillName(result models.ExecutionResult, stream string) string {
+	folder := unsafeFileChars.ReplaceAllString(result.FolderName, "_")
+	command := unsafeFileChars.ReplaceAllString(result.CommandName, "_")
+	hash := fnv.New32a()
+	hash.Write([]byte(result.FolderPath + "\x00" + result.CommandName))
+	return fmt.Sprintf("%s__%s-%08x.%s.log", folder, command, hash.Sum32(), stream)
+}
+
+// runSpillDir is the directory a batch spills into: a subdirectory of
+// spillDir named after the start of the run, so that later runs do not
+// overwrite or remove files that earlier reports link to.
+func runSpillDir(spillDir string, start time.Time) string {
+	if spillDir == "" {
+		return ""
+	}
+	return filepath.Join(spillDir, start.Format("2006-01-02-150405.000"))
+}
+
+// maxOutputFor returns the output limit of a command
+func (o Options) maxOutputFor(command models.Command) models.ByteSize {
+	switch {
+	case command.MaxOutput != 0:
+		return command.MaxOutput
+	case o.MaxOutput != 0:
+		return o.MaxOutput
+	default:
+		return DefaultMaxOutput
+	}
+}
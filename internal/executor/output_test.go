@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestRing(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		want   string
+	}{
+		{name: "empty", size: 4, want: ""},
+		{name: "filling", size: 4, writes: []string{"ab"}, want: "ab"},
+		{name: "exactly full", size: 4, writes: []string{"ab", "cd"}, want: "abcd"},
+		{name: "wraps", size: 4, writes: []string{"abc", "def"}, want: "cdef"},
+		{name: "wraps twice", size: 4, writes: []string{"abc", "de", "fgh"}, want: "efgh"},
+		{name: "single large write", size: 4, writes: []string{"abcdefgh"}, want: "efgh"},
+		{name: "large write after wrap", size: 4, writes: []string{"abcde", "123456"}, want: "3456"},
+		{name: "zero size", size: 0, writes: []string{"abc"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ring{size: tt.size}
+			for _, w := range tt.writes {
+				r.write([]byte(w))
+			}
+			if got := string(r.bytes()); got != tt.want {
+				t.Errorf("bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     models.ByteSize
+		writes    []string
+		want      string
+		truncated int64
+	}{
+		{
+			name:   "fits",
+			limit:  16,
+			writes: []string{"hello ", "world"},
+			want:   "hello world",
+		},
+		{
+			name:   "unlimited",
+			limit:  models.Unlimited,
+			writes: []string{strings.Repeat("x", 100)},
+			want:   strings.Repeat("x", 100),
+		},
+		{
+			name:      "keeps head and tail",
+			limit:     8,
+			writes:    []string{"0123", "456789", "abcdef"},
+			want:      "0123\n[... 8 bytes truncated ...]\ncdef",
+			truncated: 8,
+		},
+		{
+			name:      "tail starts on a fresh line",
+			limit:     16,
+			writes:    []string{"one\ntwo\n", "three\nfour\nfive\nsix\n"},
+			want:      "one\ntwo\n[... 16 bytes truncated ...]\nsix\n",
+			truncated: 12,
+		},
+		{
+			// Nothing may be reserved up front for the limit
+			name:   "huge limit",
+			limit:  64 << 30,
+			writes: []string{"hi\n"},
+			want:   "hi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCappedBuffer(tt.limit)
+			for _, w := range tt.writes {
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := b.truncated(); got != tt.truncated {
+				t.Errorf("truncated() = %d, want %d", got, tt.truncated)
+			}
+			if got, total := cap(b.tail.buf)+b.head.Cap(), 2*b.total+64; got > int(total) {
+				t.Errorf("buffers hold %d bytes of capacity for %d bytes written", got, b.total)
+			}
+		})
+	}
+}
+
+func TestOutputCaptureSpill(t *testing.T) {
+	dir := t.TempDir()
+	result := models.ExecutionResult{FolderName: "api", FolderPath: "/src/api", CommandName: "Git Status"}
+
+	capture, err := newOutputCapture(8, dir, result, "stdout", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := "0123456789abcdef"
+	capture.streams["stdout"].Write([]byte(full))
+	capture.streams["stderr"].Write([]byte("short"))
+
+	kept := capture.close()
+	if len(kept) != 1 || kept["stdout"] == "" {
+		t.Fatalf("kept files = %v, want stdout only", kept)
+	}
+	data, err := os.ReadFile(kept["stdout"])
+	if err != nil || string(data) != full {
+		t.Errorf("spill file holds %q (%v), want %q", data, err, full)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("spill directory holds %d files, want only the truncated stream", len(entries))
+	}
+}
+
+func TestSpillName(t *testing.T) {
+	a := models.ExecutionResult{FolderName: "team/api", FolderPath: "/src/team/api", CommandName: "Git Status"}
+	b := models.ExecutionResult{FolderName: "team_api", FolderPath: "/src/team_api", CommandName: "Git Status"}
+
+	nameA, nameB := spillName(a, "stdout"), spillName(b, "stdout")
+	if nameA == nameB {
+		t.Errorf("folders %q and %q share the spill file %q", a.FolderName, b.FolderName, nameA)
+	}
+	if !strings.HasPrefix(nameA, "team_api__Git_Status-") || !strings.HasSuffix(nameA, ".stdout.log") {
+		t.Errorf("spillName = %q", nameA)
+	}
+}
+
+func TestRunSpillDir(t *testing.T) {
+	start := time.Date(2024, 5, 1, 13, 4, 5, 0, time.UTC)
+	if got, want := runSpillDir("out", start), filepath.Join("out", "2024-05-01-130405.000"); got != want {
+		t.Errorf("runSpillDir = %q, want %q", got, want)
+	}
+	if got := runSpillDir("", start); got != "" {
+		t.Errorf("runSpillDir with no spill dir = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestExecuteOnFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		folders   []string
+		commands  []models.Command
+		onFailure models.FailurePolicy
+		want      []string
+	}{
+		{
+			name:    "continue",
+			folders: []string{"a", "b"},
+			commands: []models.Command{
+				{Name: "fail", Shell: "exit 1"},
+				{Name: "next", Shell: "true"},
+			},
+			onFailure: models.PolicyContinue,
+			want: []string{
+				"a/fail: failed",
+				"a/next: success",
+				"b/fail: failed",
+				"b/next: success",
+			},
+		},
+		{
+			name:    "stop folder",
+			folders: []string{"a", "b"},
+			commands: []models.Command{
+				// Fails in folder a only
+				{Name: "check", Shell: "[ {{.Folder.Name}} != a ]", Template: true},
+				{Name: "next", Shell: "true"},
+			},
+			onFailure: models.PolicyStopFolder,
+			want: []string{
+				"a/check: failed",
+				"a/next: skipped",
+				"b/check: success",
+				"b/next: success",
+			},
+		},
+		{
+			name:    "stop all",
+			folders: []string{"a", "b"},
+			commands: []models.Command{
+				{Name: "fail", Shell: "exit 1"},
+				{Name: "next", Shell: "true"},
+			},
+			onFailure: models.PolicyStopAll,
+			want: []string{
+				"a/fail: failed",
+				"a/next: skipped",
+				"b/fail: skipped",
+				"b/next: skipped",
+			},
+		},
+		{
+			name:    "continue on error",
+			folders: []string{"a"},
+			commands: []models.Command{
+				{Name: "fail", Shell: "exit 1", ContinueOnError: true},
+				{Name: "next", Shell: "true"},
+			},
+			onFailure: models.PolicyStopAll,
+			want: []string{
+				"a/fail: failed",
+				"a/next: success",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folders := testFolders(t, tt.folders...)
+
+			// A single worker makes the order, and so the policy outcome,
+			// deterministic
+			opts := Options{Concurrency: 1, OnFailure: tt.onFailure}
+			results := Execute(context.Background(), folders, tt.commands, opts)
+
+			got := statuses(results)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExecuteStopReason(t *testing.T) {
+	folders := testFolders(t, "a")
+	commands := []models.Command{
+		{Name: "fail", Shell: "exit 1"},
+		{Name: "next", Shell: "true"},
+	}
+
+	results := Execute(context.Background(), folders, commands, Options{Concurrency: 1, OnFailure: models.PolicyStopFolder})
+	if got, want := results[1].Error, "folder stopped: fail failed in a"; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestExecuteCommandProcessGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  models.Command
+		status   models.ResultStatus
+		exitCode int
+		stdout   string
+		err      string
+	}{
+		{
+			// The background child holds stdout open; it is killed once
+			// the shell exits instead of failing the command
+			name:     "lingering child",
+			command:  models.Command{Name: "bg", Shell: "(sleep 30; echo late) & echo early"},
+			status:   models.StatusSuccess,
+			exitCode: 0,
+			stdout:   "early\n",
+		},
+		{
+			name:     "timeout kills children",
+			command:  models.Command{Name: "slow", Shell: "(sleep 30) & sleep 30", Timeout: 200 * time.Millisecond},
+			status:   models.StatusTimedOut,
+			exitCode: -1,
+			err:      "killed after 200ms",
+		},
+		{
+			name:     "failure",
+			command:  models.Command{Name: "fail", Shell: "echo out; echo err >&2; exit 3"},
+			status:   models.StatusFailed,
+			exitCode: 3,
+			stdout:   "out\n",
+			err:      "exit status 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := testFolders(t, "a")[0]
+
+			start := time.Now()
+			result := ExecuteCommand(context.Background(), folder, tt.command, Options{})
+			// Waiting out waitDelay would mean the pipes stayed open
+			if elapsed := time.Since(start); elapsed >= waitDelay {
+				t.Errorf("took %s, children were not killed", elapsed)
+			}
+
+			if result.Status != tt.status || result.ExitCode != tt.exitCode {
+				t.Errorf("status %q with exit code %d, want %q with %d", result.Status, result.ExitCode, tt.status, tt.exitCode)
+			}
+			if result.Stdout != tt.stdout {
+				t.Errorf("Stdout = %q, want %q", result.Stdout, tt.stdout)
+			}
+			if result.Error != tt.err {
+				t.Errorf("Error = %q, want %q", result.Error, tt.err)
+			}
+		})
+	}
+}
+
+func TestExecuteCancel(t *testing.T) {
+	folders := testFolders(t, "a", "b")
+	commands := []models.Command{
+		{Name: "slow", Shell: "sleep 10"},
+		{Name: "after", Shell: "true", DependsOn: []string{"slow"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	results := Execute(ctx, folders, commands, Options{Concurrency: 1})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Execute took %s after cancelling", elapsed)
+	}
+
+	for _, result := range results {
+		if result.Status != models.StatusCancelled {
+			t.Errorf("%s/%s: status %q, want %q", result.FolderName, result.CommandName, result.Status, models.StatusCancelled)
+		}
+	}
+	if got := results[0].Error; got != "killed when the batch was cancelled" {
+		t.Errorf("running command error = %q", got)
+	}
+	if got := results[2].Error; got != "not run: batch was cancelled" {
+		t.Errorf("queued command error = %q", got)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group so that
+// cancelling it also kills any children it spawned (e.g. sh -c pipelines).
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
+
+// killProcessGroup kills whatever is still running in the command's process
+// group, such as background children left behind after it exited
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// shellCommand runs script with sh. The command name becomes $0 so that
+// args are available as $1, $2, ...
+func shellCommand(script, name string, args []string) (string, []string) {
+	return "sh", append([]string{"-c", script, name}, args...)
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package executor
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows; cancellation kills only the
+// direct child process.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup is a no-op on Windows, where children are not tracked
+func killProcessGroup(cmd *exec.Cmd) error { return nil }
+
+// shellCommand runs script with cmd.exe. Positional parameters are not
+// supported there, so args are appended to the script.
+func shellCommand(script, name string, args []string) (string, []string) {
+	return "cmd", append([]string{"/C", script}, args...)
+}
@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// Format identifies the file format of a results report
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatJUnit    Format = "junit"
+)
+
+// ParseFormat validates a format name given on the command line
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "junit", "xml":
+		return FormatJUnit, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q (want markdown, json, ndjson or junit)", name)
+	}
+}
+
+// FormatFromPath picks a format from the output file extension, falling back
+// to Markdown for unknown extensions.
+func FormatFromPath(path string) Format {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format, err := ParseFormat(ext); err == nil {
+		return format
+	}
+	return FormatMarkdown
+}
+
+// Extension returns the file extension, including the dot, for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatJSON:
+		return ".json"
+	case FormatNDJSON:
+		return ".ndjson"
+	case FormatJUnit:
+		return ".xml"
+	default:
+		return ".md"
+	}
+}
+
+// DefaultOutputPath returns a timestamped report file name for the format
+func DefaultOutputPath(format Format) string {
+	return fmt.Sprintf("multi-cmd-results-%s%s", time.Now().Format("2006-01-02-150405"), format.Extension())
+}
+
+// ReportWriter receives results while a batch runs and produces the report
+type ReportWriter interface {
+	// Add is called as each result finishes, in completion order.
+	Add(result models.ExecutionResult) error
+	// Close finishes the report. results holds every result in
+	// folder/command order.
+	Close(results []models.ExecutionResult) error
+}
+
+// NewReportWriter creates a writer for outputPath. An empty format is
+// inferred from the file extension.
+func NewReportWriter(format Format, outputPath string) (ReportWriter, error) {
+	if format == "" {
+		format = FormatFromPath(outputPath)
+	}
+
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{path: outputPath}, nil
+	case FormatNDJSON:
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create report file: %w", err)
+		}
+		return &ndjsonWriter{file: file, enc: json.NewEncoder(file)}, nil
+	case FormatJUnit:
+		return &junitWriter{path: outputPath}, nil
+	default:
+		return &markdownWriter{path: outputPath}, nil
+	}
+}
+
+type markdownWriter struct {
+	path string
+}
+
+func (w *markdownWriter) Add(models.ExecutionResult) error { return nil }
+
+func (w *markdownWriter) Close(results []models.ExecutionResult) error {
+	return WriteResults(results, w.path)
+}
+
+// jsonReport is the document written by the JSON format
+type jsonReport struct {
+	GeneratedAt time.Time                `json:"generated_at"`
+	Cancelled   bool                     `json:"cancelled"`
+	Results     []models.ExecutionResult `json:"results"`
+}
+
+type jsonWriter struct {
+	path string
+}
+
+func (w *jsonWriter) Add(models.ExecutionResult) error { return nil }
+
+func (w *jsonWriter) Close(results []models.ExecutionResult) error {
+	if results == nil {
+		results = []models.ExecutionResult{}
+	}
+
+	data, err := json.MarshalIndent(jsonReport{
+		GeneratedAt: time.Now(),
+		Cancelled:   countStatus(results, models.StatusCancelled) > 0,
+		Results:     results,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(w.path, append(data, '\n'), 0644)
+}
+
+// ndjsonWriter streams one JSON line per result as soon as it finishes
+type ndjsonWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func (w *ndjsonWriter) Add(result models.ExecutionResult) error {
+	return w.enc.Encode(result)
+}
+
+func (w *ndjsonWriter) Close([]models.ExecutionResult) error {
+	return w.file.Close()
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// defaultRetryBackoff is the wait before the first retry when a command does
+// not set retry_backoff. The wait doubles after every attempt.
+const defaultRetryBackoff = time.Second
+
+// retryPattern compiles the command's retry_on expression, if any
+func retryPattern(command models.Command) (*regexp.Regexp, error) {
+	if command.RetryOn == "" {
+		return nil, nil
+	}
+	pattern, err := regexp.Compile(command.RetryOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry_on: %w", err)
+	}
+	return pattern, nil
+}
+
+// retryable reports whether a failed attempt may be retried. Failures and
+// timeouts are, unless retry_on is set and does not match stderr.
+func retryable(result models.ExecutionResult, retryOn *regexp.Regexp) bool {
+	if result.Status != models.StatusFailed && result.Status != models.StatusTimedOut {
+		return false
+	}
+	return retryOn == nil || retryOn.MatchString(result.Stderr)
+}
+
+// attemptError describes a failed attempt by its error and the last line it
+// wrote to stderr
+func attemptError(result models.ExecutionResult) string {
+	lines := strings.Split(strings.TrimSpace(result.Stderr), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return result.Error + ": " + last
+	}
+	return result.Error
+}
+
+// sleep waits for d and reports false if ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+func TestExecuteCancelDuringRetryBackoff(t *testing.T) {
+	folders := testFolders(t, "a")
+	commands := []models.Command{
+		{Name: "flaky", Shell: "exit 1", Retries: 3, RetryBackoff: 10 * time.Second},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	result := Execute(ctx, folders, commands, Options{})[0]
+	if result.Status != models.StatusCancelled {
+		t.Errorf("status %q, want %q", result.Status, models.StatusCancelled)
+	}
+	if result.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", result.Attempts)
+	}
+}
+
+func TestExecuteRetries(t *testing.T) {
+	folders := testFolders(t, "a")
+	// Fails on the first two attempts, counting them in a file
+	script := `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $n -ge 3 ]`
+	commands := []models.Command{
+		{Name: "flaky", Shell: script, Retries: 2, RetryBackoff: time.Millisecond},
+	}
+
+	result := Execute(context.Background(), folders, commands, Options{})[0]
+	if result.Status != models.StatusSuccess {
+		t.Fatalf("status %q, want %q", result.Status, models.StatusSuccess)
+	}
+	if result.Attempts != 3 || len(result.AttemptErrors) != 2 {
+		t.Errorf("Attempts = %d with errors %q, want 3 attempts and 2 errors", result.Attempts, result.AttemptErrors)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	network := regexp.MustCompile("Could not resolve host")
+	tests := []struct {
+		name    string
+		status  models.ResultStatus
+		stderr  string
+		retryOn *regexp.Regexp
+		want    bool
+	}{
+		{name: "failed", status: models.StatusFailed, want: true},
+		{name: "timed out", status: models.StatusTimedOut, want: true},
+		{name: "cancelled", status: models.StatusCancelled, want: false},
+		{name: "skipped", status: models.StatusSkipped, want: false},
+		{name: "retry_on matches", status: models.StatusFailed, stderr: "fatal: Could not resolve host: example.com", retryOn: network, want: true},
+		{name: "retry_on does not match", status: models.StatusFailed, stderr: "fatal: not a git repository", retryOn: network, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := models.ExecutionResult{Status: tt.status, Stderr: tt.stderr}
+			if got := retryable(result, tt.retryOn); got != tt.want {
+				t.Errorf("retryable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttemptError(t *testing.T) {
+	tests := []struct {
+		err, stderr, want string
+	}{
+		{"exit status 1", "", "exit status 1"},
+		{"exit status 1", "warning\nfatal: unable to access\n\n", "exit status 1: fatal: unable to access"},
+		{"killed after 1s", "  \n", "killed after 1s"},
+	}
+
+	for _, tt := range tests {
+		result := models.ExecutionResult{Error: tt.err, Stderr: tt.stderr}
+		if got := attemptError(result); got != tt.want {
+			t.Errorf("attemptError(%q, %q) = %q, want %q", tt.err, tt.stderr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// TemplateData is what command templates can refer to, e.g.
+// {{.Folder.Name}}, {{.Folder.Path}}, {{.ScanRoot}} or {{.Vars.name}}.
+type TemplateData struct {
+	Folder   models.Folder
+	ScanRoot string
+	Vars     map[string]string
+
+	// enabled is false for commands that do not opt in to templates
+	enabled bool
+}
+
+func newTemplateData(folder models.Folder, command models.Command, vars map[string]string) TemplateData {
+	merged := make(map[string]string, len(vars)+len(command.Vars))
+	for name, value := range vars {
+		merged[name] = value
+	}
+	for name, value := range command.Vars {
+		merged[name] = value
+	}
+	return TemplateData{Folder: folder, ScanRoot: folder.Root, Vars: merged, enabled: command.Template}
+}
+
+// expandTemplate expands a shell or argument template of a command that
+// sets template: true. Referring to a var that is not defined is an error
+// rather than an empty string.
+func expandTemplate(name, text string, data TemplateData) (string, error) {
+	if !data.enabled || !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// commandLine expands the command's templates for a folder. It returns the
+// program and arguments to run and the command line shown in reports, which
+// for shell commands is the script rather than the shell invocation.
+func commandLine(folder models.Folder, command models.Command, vars map[string]string) (name string, args []string, display string, err error) {
+	data := newTemplateData(folder, command, vars)
+
+	args = make([]string, len(command.Args))
+	for i, arg := range command.Args {
+		if args[i], err = expandTemplate(fmt.Sprintf("args[%d]", i), arg, data); err != nil {
+			return "", nil, "", fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	if command.Shell == "" {
+		return command.Cmd, args, joinCommand(command.Cmd, args), nil
+	}
+
+	script, err := expandTemplate("shell", command.Shell, data)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("invalid template: %w", err)
+	}
+	name, shellArgs := shellCommand(script, command.Name, args)
+	return name, shellArgs, joinCommand(script, args), nil
+}
+
+// CheckTemplates reports template errors in a command, such as syntax
+// errors, unknown fields or vars that are not defined. Every folder field is
+// filled in, Git included, so that only real mistakes are reported.
+func CheckTemplates(command models.Command, vars map[string]string) error {
+	folder := models.Folder{Git: &models.GitInfo{}}
+	_, _, _, err := commandLine(folder, command, vars)
+	return err
+}
+
+func joinCommand(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
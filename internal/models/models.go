@@ -1,31 +1,178 @@
 package models
 
+import "time"
+
 // Command represents a command that can be executed on folders
 type Command struct {
-	Name string   `yaml:"name"`
-	Cmd  string   `yaml:"cmd"`
-	Args []string `yaml:"args"`
+	Name string `yaml:"name"`
+	Cmd  string `yaml:"cmd"`
+	// Shell is a script run with the system shell instead of Cmd. Args
+	// are passed to it as positional parameters ($1, $2, ...).
+	Shell string   `yaml:"shell"`
+	Args  []string `yaml:"args"`
+	// Template expands Shell and Args as Go templates. It is opt-in so
+	// that arguments such as go list -f '{{.ImportPath}}' pass through.
+	Template bool `yaml:"template"`
+	// Vars are available to Shell and Args templates as {{.Vars.name}}
+	// and override the config-level vars.
+	Vars map[string]string `yaml:"vars"`
+	// Env is added to the environment the command runs with. ${VAR}
+	// references are expanded from the inherited environment.
+	Env map[string]string `yaml:"env"`
+	// Workdir is the directory to run in, relative to the folder
+	Workdir string        `yaml:"workdir"`
+	Timeout time.Duration `yaml:"timeout"`
+	Tags    []string      `yaml:"tags"`
+	// DependsOn names commands that must succeed in a folder before this
+	// command runs there
+	DependsOn []string `yaml:"depends_on"`
+	// ContinueOnError keeps a failure of this command from triggering the
+	// on_failure policy
+	ContinueOnError bool `yaml:"continue_on_error"`
+	// Retries is how many more times a failed or timed out command is run.
+	// The first retry waits RetryBackoff (1s when unset), doubling after
+	// every attempt. With RetryOn set, only failures whose stderr matches
+	// the regular expression are retried.
+	Retries      int           `yaml:"retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	RetryOn      string        `yaml:"retry_on"`
+	// MaxOutput caps how much of each output stream is kept, overriding
+	// the config-level max_output
+	MaxOutput ByteSize `yaml:"max_output"`
+	// When limits the folders the command runs in; elsewhere it is
+	// recorded as skipped.
+	When *Condition `yaml:"when"`
+	// CombinedOutput also captures stdout and stderr interleaved in the
+	// order they were written.
+	CombinedOutput bool `yaml:"combined_output"`
+}
+
+// Condition decides whether a command applies to a folder. Every field that
+// is set must hold. Paths and globs are relative to the folder.
+type Condition struct {
+	// Exists lists files or directories that must all exist
+	Exists []string `yaml:"exists"`
+	// Glob lists patterns that must each match at least one path
+	Glob []string `yaml:"glob"`
+	// Branch is a glob the checked-out git branch must match
+	Branch string `yaml:"branch"`
+	// Env lists environment variables that must be set and non-empty
+	Env []string `yaml:"env"`
+	// Probe is a shell script run in the folder; the command applies when
+	// it exits with ProbeExit
+	Probe     string `yaml:"probe"`
+	ProbeExit int    `yaml:"probe_exit"`
+}
+
+// Profile is a named selection of commands and folders
+type Profile struct {
+	Name string `yaml:"name"`
+	// Commands and Tags select commands by name or by tag
+	Commands []string `yaml:"commands"`
+	Tags     []string `yaml:"tags"`
+	// Folders holds glob patterns matched against folder names
+	Folders []string `yaml:"folders"`
 }
 
 // Config represents the application configuration
 type Config struct {
+	// Include lists other config files (relative paths and globs) that
+	// this file is layered on top of
+	Include  []string  `yaml:"include"`
 	Commands []Command `yaml:"commands"`
+	Profiles []Profile `yaml:"profiles"`
+	// Vars are available to every command's templates
+	Vars map[string]string `yaml:"vars"`
+	// Env is added to the environment of every command
+	Env         map[string]string `yaml:"env"`
+	Concurrency int               `yaml:"concurrency"`
+	OnFailure   FailurePolicy     `yaml:"on_failure"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	// MaxOutput caps how much of each output stream is kept in memory and
+	// in the report; the start and the end are kept
+	MaxOutput ByteSize `yaml:"max_output"`
+	// SpillDir, when set, receives the full output of every result that
+	// was truncated
+	SpillDir      string   `yaml:"spill_dir"`
+	MaxDepth      int      `yaml:"max_depth"`
+	Markers       []string `yaml:"markers"`
+	Ignore        []string `yaml:"ignore"`
+	IncludeHidden bool     `yaml:"include_hidden"`
+	GitOnly       bool     `yaml:"git_only"`
+	Folders       []string `yaml:"folders"`
+	Workspaces    bool     `yaml:"workspaces"`
 }
 
+// FailurePolicy decides what happens to the rest of a batch after a command
+// fails or times out
+type FailurePolicy string
+
+const (
+	// PolicyContinue runs everything regardless of failures (the default)
+	PolicyContinue FailurePolicy = "continue"
+	// PolicyStopFolder skips the remaining commands in the failed folder
+	PolicyStopFolder FailurePolicy = "stop_folder"
+	// PolicyStopAll skips every command that has not started yet
+	PolicyStopAll FailurePolicy = "stop_all"
+)
+
 // Folder represents a selectable folder discovered in the scan path
 type Folder struct {
-	Path     string
-	Name     string
+	Path string
+	Name string
+	// Root is the scan root the folder was found under
+	Root     string
 	Selected bool
+	// Git is only filled in git-only mode
+	Git *GitInfo
+}
+
+// GitInfo describes the state of a git working tree
+type GitInfo struct {
+	Branch      string
+	Dirty       bool
+	HasUpstream bool
+	Ahead       int
+	Behind      int
 }
 
+// ResultStatus describes how a command execution ended
+type ResultStatus string
+
+const (
+	StatusSuccess   ResultStatus = "success"
+	StatusFailed    ResultStatus = "failed"
+	StatusTimedOut  ResultStatus = "timed out"
+	StatusCancelled ResultStatus = "cancelled"
+	StatusSkipped   ResultStatus = "skipped"
+)
+
 // ExecutionResult represents the result of executing a command on a folder
 type ExecutionResult struct {
-	FolderName      string
-	FolderPath      string
-	CommandName     string
-	CommandExecuted string
-	Output          string
-	Error           string
-	Success         bool
+	FolderName      string       `json:"folder_name"`
+	FolderPath      string       `json:"folder_path"`
+	CommandName     string       `json:"command_name"`
+	CommandExecuted string       `json:"command_executed"`
+	Stdout          string       `json:"stdout"`
+	Stderr          string       `json:"stderr"`
+	Combined        string       `json:"combined,omitempty"`
+	Error           string       `json:"error,omitempty"`
+	Success         bool         `json:"success"`
+	Status          ResultStatus `json:"status"`
+	// ExitCode is -1 when the process never started or did not exit on its
+	// own (binary not found, killed, cancelled before running).
+	ExitCode  int       `json:"exit_code"`
+	StartTime time.Time `json:"start_time"`
+	// Duration covers every attempt and the waits between them
+	Duration time.Duration `json:"duration_ns"`
+	// Attempts is how many times the command was run; AttemptErrors holds
+	// the error of each failed attempt, in order.
+	Attempts      int      `json:"attempts"`
+	AttemptErrors []string `json:"attempt_errors,omitempty"`
+	// Truncated is set when an output stream went over the size limit.
+	// OutputFiles maps the truncated streams ("stdout", "stderr",
+	// "combined") to the files holding their full output, when spilling
+	// is enabled.
+	Truncated   bool              `json:"truncated,omitempty"`
+	OutputFiles map[string]string `json:"output_files,omitempty"`
 }
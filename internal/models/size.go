@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a size in bytes written in YAML as a number or with a unit:
+// 512, 64KB, 10MB, 1GB (units are powers of 1024). "unlimited" is -1.
+type ByteSize int64
+
+// Unlimited disables a size limit
+const Unlimited ByteSize = -1
+
+var byteUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GB", 1 << 30}, {"G", 1 << 30},
+	{"MB", 1 << 20}, {"M", 1 << 20},
+	{"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as "10MB"
+func ParseByteSize(text string) (ByteSize, error) {
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, "unlimited") {
+		return Unlimited, nil
+	}
+
+	upper := strings.ToUpper(text)
+	unit := ByteSize(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 512KB, 10MB or unlimited)", text)
+	}
+	return ByteSize(n) * unit, nil
+}
+
+func (s *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	size, err := ParseByteSize(node.Value)
+	if err != nil {
+		// A TypeError lets decoding continue and report other problems
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d:%d: %v", node.Line, node.Column, err)}}
+	}
+	*s = size
+	return nil
+}
+
+func (s ByteSize) String() string {
+	if s < 0 {
+		return "unlimited"
+	}
+	for _, u := range byteUnits {
+		if len(u.suffix) == 2 && s >= u.size && s%u.size == 0 {
+			return strconv.FormatInt(int64(s/u.size), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(s), 10) + "B"
+}
@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+)
+
+// gitWorkers bounds how many git processes run while annotating folders
+const gitWorkers = 8
+
+// isGitWorkTree reports whether dir is the top of a git working tree. A .git
+// file (rather than directory) is used by linked worktrees, submodules and
+// checkouts whose repository lives next to them, e.g. a sibling .bare dir.
+func isGitWorkTree(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// filterGit keeps only git working trees and annotates them with their
+// branch, dirty flag and ahead/behind counts.
+func filterGit(folders []models.Folder) []models.Folder {
+	var repos []models.Folder
+	for _, folder := range folders {
+		if isGitWorkTree(folder.Path) {
+			repos = append(repos, folder)
+		}
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < gitWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				repos[j].Git = readGitInfo(repos[j].Path)
+			}
+		}()
+	}
+	for i := range repos {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return repos
+}
+
+// readGitInfo parses `git status --porcelain=v2 --branch`. It returns an
+// empty GitInfo when git fails so that the folder is still listed.
+func readGitInfo(dir string) *models.GitInfo {
+	info := &models.GitInfo{}
+
+	cmd := exec.Command("git", "status", "--porcelain=v2", "--branch")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return info
+	}
+
+	lines := bufio.NewScanner(bytes.NewReader(out))
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			info.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			info.HasUpstream = true
+			for _, field := range strings.Fields(strings.TrimPrefix(line, "# branch.ab ")) {
+				n, _ := strconv.Atoi(field[1:])
+				if field[0] == '+' {
+					info.Ahead = n
+				} else {
+					info.Behind = n
+				}
+			}
+		case strings.HasPrefix(line, "#"):
+		case line != "":
+			info.Dirty = true
+		}
+	}
+
+	return info
+}
+
+// GitSummary renders the dirty flag and ahead/behind counts, e.g. "dirty ↑2 ↓0"
+func GitSummary(info *models.GitInfo) string {
+	if info == nil {
+		return ""
+	}
+
+	state := "clean"
+	if info.Dirty {
+		state = "dirty"
+	}
+	if !info.HasUpstream {
+		return state + " (no upstream)"
+	}
+	return fmt.Sprintf("%s ↑%d ↓%d", state, info.Ahead, info.Behind)
+}
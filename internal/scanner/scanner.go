@@ -0,0 +1,314 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMarkers identify a project root when no markers are configured
+var DefaultMarkers = []string{".git", "go.mod", "package.json"}
+
+// IgnoreFile is read from the scan root for extra ignore patterns, one per
+// line. Blank lines and lines starting with # are skipped.
+const IgnoreFile = ".multi-cmdignore"
+
+// Options controls how folders are discovered
+type Options struct {
+	// Roots are the directories whose subfolders are listed
+	Roots []string
+	// Folders are listed as-is in addition to what is found under Roots
+	Folders []string
+	// MaxDepth is how many directory levels below the scan path are
+	// searched. Values below 2 list only the immediate subfolders.
+	MaxDepth int
+	// Markers are file or directory names (globs allowed) that mark a
+	// project root. Discovery stops descending once one is found.
+	Markers []string
+	// Ignore holds glob patterns matched against a folder's name and its
+	// path relative to the scan root. Ignored folders are not descended.
+	Ignore []string
+	// IncludeHidden lists folders whose name starts with a dot
+	IncludeHidden bool
+	// GitOnly keeps only git working trees and fills in Folder.Git
+	GitOnly bool
+	// Workspaces replaces folders holding a workspace manifest (go.work,
+	// package.json or pnpm-workspace.yaml workspaces, Cargo.toml
+	// [workspace]) with the workspace members.
+	Workspaces bool
+}
+
+// OptionsFromConfig builds scan options from the config file settings
+func OptionsFromConfig(cfg *models.Config) Options {
+	return Options{
+		Folders:       cfg.Folders,
+		MaxDepth:      cfg.MaxDepth,
+		Markers:       cfg.Markers,
+		Ignore:        cfg.Ignore,
+		IncludeHidden: cfg.IncludeHidden,
+		GitOnly:       cfg.GitOnly,
+		Workspaces:    cfg.Workspaces,
+	}
+}
+
+// Scan lists the folders found under every root followed by the explicitly
+// listed folders. With a depth of 1 every immediate subfolder of a root is
+// returned; deeper scans return only project roots, named by their path
+// relative to the root. Duplicates are dropped, and folders that share a
+// name are prefixed with the name of their root.
+func Scan(opts Options) []models.Folder {
+	if len(opts.Markers) == 0 {
+		opts.Markers = DefaultMarkers
+	}
+
+	var folders []models.Folder
+	for _, root := range absPaths(opts.Roots) {
+		if opts.Workspaces && len(workspaceMembers(root)) > 0 {
+			// A root that is itself a workspace offers its members
+			folders = append(folders, models.Folder{Path: root, Root: root})
+			continue
+		}
+
+		rootOpts := opts
+		rootOpts.Ignore = append(append([]string(nil), opts.Ignore...), readIgnoreFile(root)...)
+		walk(root, root, 1, rootOpts, &folders)
+	}
+
+	for _, folderPath := range absPaths(opts.Folders) {
+		if info, err := os.Stat(folderPath); err != nil || !info.IsDir() {
+			continue
+		}
+		folders = append(folders, models.Folder{
+			Path: folderPath,
+			Name: filepath.Base(folderPath),
+			Root: filepath.Dir(folderPath),
+		})
+	}
+
+	if opts.Workspaces {
+		folders = expandWorkspaces(folders)
+	}
+	folders = disambiguate(dedupe(folders))
+
+	if opts.GitOnly {
+		folders = filterGit(folders)
+	}
+	return folders
+}
+
+func absPaths(paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			result = append(result, abs)
+		}
+	}
+	return result
+}
+
+func dedupe(folders []models.Folder) []models.Folder {
+	seen := make(map[string]bool)
+	var unique []models.Folder
+	for _, folder := range folders {
+		if seen[folder.Path] {
+			continue
+		}
+		seen[folder.Path] = true
+		unique = append(unique, folder)
+	}
+	return unique
+}
+
+// disambiguate prefixes clashing names with the base name of their root,
+// falling back to the full path when the roots share a name too.
+func disambiguate(folders []models.Folder) []models.Folder {
+	counts := make(map[string]int)
+	for _, folder := range folders {
+		counts[folder.Name]++
+	}
+
+	prefixed := make(map[string]int)
+	for i, folder := range folders {
+		if counts[folder.Name] > 1 {
+			folders[i].Name = filepath.Base(folder.Root) + "/" + folder.Name
+			prefixed[folders[i].Name]++
+		}
+	}
+
+	for i, folder := range folders {
+		if prefixed[folder.Name] > 1 {
+			folders[i].Name = filepath.ToSlash(folder.Path)
+		}
+	}
+	return folders
+}
+
+// ReadFolderList reads folder paths from a file with one path per line, or
+// from the folders: section of a YAML file. Relative paths are resolved
+// against the directory of the file.
+func ReadFolderList(listPath string) ([]string, error) {
+	data, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder list: %w", err)
+	}
+
+	var paths []string
+	switch filepath.Ext(listPath) {
+	case ".yaml", ".yml":
+		var doc struct {
+			Folders []string `yaml:"folders"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse folder list: %w", err)
+		}
+		paths = doc.Folders
+	default:
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			paths = append(paths, line)
+		}
+	}
+
+	return resolvePaths(filepath.Dir(listPath), paths), nil
+}
+
+// resolvePaths joins relative paths onto baseDir
+func resolvePaths(baseDir string, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		if filepath.IsAbs(p) {
+			resolved[i] = p
+		} else {
+			resolved[i] = filepath.Join(baseDir, p)
+		}
+	}
+	return resolved
+}
+
+func walk(basePath, dir string, depth int, opts Options, folders *[]models.Folder) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		fullPath := filepath.Join(dir, entry.Name())
+		rel, err := filepath.Rel(basePath, fullPath)
+		if err != nil {
+			rel = entry.Name()
+		}
+		rel = filepath.ToSlash(rel)
+
+		if opts.skip(entry.Name(), rel) {
+			continue
+		}
+
+		if opts.MaxDepth < 2 || isProjectRoot(fullPath, opts.Markers) {
+			*folders = append(*folders, models.Folder{
+				Path:     fullPath,
+				Name:     rel,
+				Root:     basePath,
+				Selected: false,
+			})
+			continue
+		}
+
+		if depth < opts.MaxDepth {
+			walk(basePath, fullPath, depth+1, opts, folders)
+		}
+	}
+}
+
+func (o Options) skip(name, rel string) bool {
+	if !o.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	for _, pattern := range o.Ignore {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func readIgnoreFile(basePath string) []string {
+	file, err := os.Open(filepath.Join(basePath, IgnoreFile))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []string
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+func isProjectRoot(dir string, markers []string) bool {
+	for _, marker := range markers {
+		matches, err := filepath.Glob(filepath.Join(dir, marker))
+		if err == nil && len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Select marks the folders whose name matches any of the glob patterns as
+// selected. With no patterns every folder is selected.
+func Select(folders []models.Folder, patterns []string) ([]models.Folder, error) {
+	for i := range folders {
+		if len(patterns) == 0 {
+			folders[i].Selected = true
+			continue
+		}
+
+		matched, err := Match(folders[i].Name, patterns)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			folders[i].Selected = true
+		}
+	}
+
+	return folders, nil
+}
+
+// Match reports whether a folder name matches any of the glob patterns
+func Match(name string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,361 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ramayac/multi-cmd/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// workspaceKind describes one kind of workspace manifest. members returns the
+// member patterns it declares, or nil when the manifest is absent; patterns
+// starting with ! exclude members. Glob matches only count as members when
+// they contain memberFile. Literal kinds declare plain paths, which may
+// point outside the workspace folder or into hidden directories.
+type workspaceKind struct {
+	members    func(dir string) []string
+	memberFile string
+	literal    bool
+}
+
+var workspaceKinds = []workspaceKind{
+	{goWorkMembers, "", true},
+	{pnpmWorkspaceMembers, "package.json", false},
+	{npmWorkspaceMembers, "package.json", false},
+	{cargoWorkspaceMembers, "Cargo.toml", false},
+}
+
+// expandWorkspaces replaces every folder that holds a workspace manifest
+// with the workspace members, named below the folder's own name.
+func expandWorkspaces(folders []models.Folder) []models.Folder {
+	var expanded []models.Folder
+	for _, folder := range folders {
+		members := workspaceMembers(folder.Path)
+		if len(members) == 0 {
+			expanded = append(expanded, folder)
+			continue
+		}
+
+		for _, member := range members {
+			memberPath := filepath.Join(folder.Path, filepath.FromSlash(member))
+			name := path.Join(folder.Name, member)
+			if name == "." {
+				name = filepath.Base(memberPath)
+			}
+			expanded = append(expanded, models.Folder{
+				Path: memberPath,
+				Name: name,
+				Root: folder.Root,
+			})
+		}
+	}
+	return expanded
+}
+
+// workspaceMembers returns the member directories of every workspace
+// manifest found in dir, relative to dir and sorted.
+func workspaceMembers(dir string) []string {
+	seen := make(map[string]bool)
+	for _, kind := range workspaceKinds {
+		if kind.literal {
+			for _, member := range literalDirs(dir, kind.members(dir)) {
+				seen[member] = true
+			}
+			continue
+		}
+
+		var excluded []string
+		var included []string
+		for _, pattern := range kind.members(dir) {
+			if strings.HasPrefix(pattern, "!") {
+				excluded = append(excluded, strings.TrimPrefix(pattern, "!"))
+			} else {
+				included = append(included, pattern)
+			}
+		}
+
+		for _, pattern := range included {
+			for _, member := range globDirs(dir, pattern) {
+				if matchesAny(member, excluded) || !kind.hasMemberFile(dir, member) {
+					continue
+				}
+				seen[member] = true
+			}
+		}
+	}
+
+	members := make([]string, 0, len(seen))
+	for member := range seen {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// literalDirs resolves member paths against dir, keeping the directories
+// that exist, as slash-separated paths relative to dir
+func literalDirs(dir string, paths []string) []string {
+	var dirs []string
+	for _, p := range paths {
+		p = filepath.FromSlash(p)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if info, err := os.Stat(p); err != nil || !info.IsDir() {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+	}
+	return dirs
+}
+
+func (k workspaceKind) hasMemberFile(dir, member string) bool {
+	if k.memberFile == "" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(member), k.memberFile))
+	return err == nil
+}
+
+func matchesAny(member string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if globMatch(strings.Split(cleanPattern(pattern), "/"), strings.Split(member, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanPattern(pattern string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(pattern), "./"))
+}
+
+// globDirs expands a slash-separated pattern, where ** matches any number of
+// directories, into the directories below base that it names.
+func globDirs(base, pattern string) []string {
+	var matches []string
+	collectDirs(base, "", strings.Split(cleanPattern(pattern), "/"), &matches)
+	return matches
+}
+
+func collectDirs(dir, rel string, segments []string, matches *[]string) {
+	if len(segments) == 0 {
+		if rel == "" {
+			rel = "."
+		}
+		*matches = append(*matches, rel)
+		return
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if segment == "." {
+		collectDirs(dir, rel, rest, matches)
+		return
+	}
+
+	if segment == "**" {
+		collectDirs(dir, rel, rest, matches)
+		for _, name := range subdirs(dir) {
+			collectDirs(filepath.Join(dir, name), path.Join(rel, name), segments, matches)
+		}
+		return
+	}
+
+	for _, name := range subdirs(dir) {
+		if matched, _ := path.Match(segment, name); matched {
+			collectDirs(filepath.Join(dir, name), path.Join(rel, name), rest, matches)
+		}
+	}
+}
+
+// subdirs lists the directories in dir, skipping hidden ones and
+// node_modules so that ** does not crawl dependency trees.
+func subdirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "node_modules" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// globMatch matches path segments against pattern segments, with ** matching
+// zero or more segments.
+func globMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return globMatch(pattern[1:], segments[1:])
+}
+
+// goWorkMembers reads the use directives of go.work
+func goWorkMembers(dir string) []string {
+	file, err := os.Open(filepath.Join(dir, "go.work"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var members []string
+	inBlock := false
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := lines.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) == 0:
+		case inBlock && fields[0] == ")":
+			inBlock = false
+		case inBlock:
+			members = append(members, strings.Trim(fields[0], `"`))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inBlock = true
+		case fields[0] == "use" && len(fields) > 1:
+			members = append(members, strings.Trim(fields[1], `"`))
+		}
+	}
+	return members
+}
+
+// npmWorkspaceMembers reads the workspaces field of package.json, which is
+// either a list of globs or an object with a packages list.
+func npmWorkspaceMembers(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Workspaces) == 0 {
+		return nil
+	}
+
+	var members []string
+	if err := json.Unmarshal(manifest.Workspaces, &members); err == nil {
+		return members
+	}
+
+	var nested struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(manifest.Workspaces, &nested); err == nil {
+		return nested.Packages
+	}
+	return nil
+}
+
+// pnpmWorkspaceMembers reads the packages list of pnpm-workspace.yaml
+func pnpmWorkspaceMembers(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "pnpm-workspace.yaml"))
+	if err != nil {
+		return nil
+	}
+
+	var manifest struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	return manifest.Packages
+}
+
+// cargoWorkspaceMembers reads members and exclude from the [workspace]
+// table of Cargo.toml. Only the string array form is understood, which is
+// what Cargo itself accepts for these keys.
+func cargoWorkspaceMembers(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil
+	}
+
+	var members []string
+	inWorkspace := false
+	key := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if key == "" && strings.HasPrefix(line, "[") {
+			inWorkspace = line == "[workspace]"
+			continue
+		}
+		if !inWorkspace {
+			continue
+		}
+
+		if key == "" {
+			name, value, ok := strings.Cut(line, "=")
+			name = strings.TrimSpace(name)
+			if !ok || (name != "members" && name != "exclude") {
+				continue
+			}
+			key, line = name, value
+		}
+
+		for _, item := range tomlStrings(line) {
+			if key == "exclude" {
+				item = "!" + item
+			}
+			members = append(members, item)
+		}
+		if strings.Contains(line, "]") {
+			key = ""
+		}
+	}
+	return members
+}
+
+// tomlStrings extracts the quoted strings from a fragment of a TOML array
+func tomlStrings(fragment string) []string {
+	var items []string
+	for {
+		start := strings.IndexAny(fragment, `"'`)
+		if start < 0 {
+			return items
+		}
+		quote := fragment[start]
+		end := strings.IndexByte(fragment[start+1:], quote)
+		if end < 0 {
+			return items
+		}
+		items = append(items, fragment[start+1:start+1+end])
+		fragment = fragment[start+end+2:]
+	}
+}
@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the files under root; names ending in / are directories
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkspaceMembers(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "go.work",
+			files: map[string]string{
+				"mono/go.work": `go 1.22
+
+use ./api // the service
+use (
+	./libs/util
+	"./.tools"
+	../shared
+	./missing
+)
+`,
+				"mono/api/":        "",
+				"mono/libs/util/":  "",
+				"mono/.tools/":     "",
+				"shared/":          "",
+				"mono/not-listed/": "",
+			},
+			want: "../shared,.tools,api,libs/util",
+		},
+		{
+			name: "npm globs and exclusions",
+			files: map[string]string{
+				"mono/package.json":                 `{"workspaces": ["packages/*", "!packages/legacy"]}`,
+				"mono/packages/web/package.json":    "{}",
+				"mono/packages/legacy/package.json": "{}",
+				"mono/packages/docs/":               "",
+			},
+			want: "packages/web",
+		},
+		{
+			name: "npm packages object",
+			files: map[string]string{
+				"mono/package.json":                     `{"workspaces": {"packages": ["apps/**"]}}`,
+				"mono/apps/a/package.json":              "{}",
+				"mono/apps/b/c/package.json":            "{}",
+				"mono/apps/node_modules/x/package.json": "{}",
+			},
+			want: "apps/a,apps/b/c",
+		},
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"mono/pnpm-workspace.yaml":   "packages:\n  - 'libs/*'\n",
+				"mono/libs/one/package.json": "{}",
+			},
+			want: "libs/one",
+		},
+		{
+			name: "cargo",
+			files: map[string]string{
+				"mono/Cargo.toml": `[package]
+name = "root"
+
+[workspace]
+members = [
+    "crates/*", # every crate
+]
+exclude = ["crates/old"]
+`,
+				"mono/crates/core/Cargo.toml": "",
+				"mono/crates/old/Cargo.toml":  "",
+			},
+			want: "crates/core",
+		},
+		{
+			name: "no manifest",
+			files: map[string]string{
+				"mono/src/": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+
+			got := strings.Join(workspaceMembers(filepath.Join(root, "mono")), ",")
+			if got != tt.want {
+				t.Errorf("workspaceMembers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"packages/*", "packages/web", true},
+		{"packages/*", "packages/web/sub", false},
+		{"**/legacy", "legacy", true},
+		{"**/legacy", "a/b/legacy", true},
+		{"apps/**", "apps/a/b", true},
+		{"./apps/*", "apps/a", true},
+		{"apps/*", "libs/a", false},
+	}
+
+	for _, tt := range tests {
+		got := globMatch(strings.Split(cleanPattern(tt.pattern), "/"), strings.Split(tt.path, "/"))
+		if got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -30,10 +30,19 @@ func (m Model) getFilteredCommands() []models.Command {
 	var filtered []models.Command
 	filterLower := strings.ToLower(m.commandFilterText)
 	for _, cmd := range m.commands {
-		if strings.Contains(strings.ToLower(cmd.Name), filterLower) {
+		if strings.Contains(strings.ToLower(cmd.Name), filterLower) || hasTagContaining(cmd, filterLower) {
 			filtered = append(filtered, cmd)
 		}
 	}
 
 	return filtered
 }
+
+func hasTagContaining(cmd models.Command, filterLower string) bool {
+	for _, tag := range cmd.Tags {
+		if strings.Contains(strings.ToLower(tag), filterLower) {
+			return true
+		}
+	}
+	return false
+}
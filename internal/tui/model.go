@@ -1,15 +1,15 @@
 package tui
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
-	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ramayac/multi-cmd/internal/config"
 	"github.com/ramayac/multi-cmd/internal/executor"
 	"github.com/ramayac/multi-cmd/internal/models"
+	"github.com/ramayac/multi-cmd/internal/scanner"
 )
 
 type view int
@@ -50,6 +50,10 @@ type keyMap struct {
 	Filter    key.Binding
 	Tab       key.Binding
 	Reset     key.Binding
+	Cancel    key.Binding
+	Hidden    key.Binding
+	Profile   key.Binding
+	ProfileN  key.Binding
 }
 
 var keys = keyMap{
@@ -97,6 +101,22 @@ var keys = keyMap{
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
 	),
+	Hidden: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "toggle hidden folders"),
+	),
+	Profile: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "next profile"),
+	),
+	ProfileN: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "apply profile"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc", "ctrl+c"),
+		key.WithHelp("esc", "cancel run"),
+	),
 }
 
 type Model struct {
@@ -104,6 +124,8 @@ type Model struct {
 	focus               focusArea
 	folders             []models.Folder
 	commands            []models.Command
+	cfg                 *models.Config
+	activeProfile       int
 	selectedCommands    map[int]bool
 	folderCursorPos     int
 	commandCursorPos    int
@@ -113,8 +135,9 @@ type Model struct {
 	folderFilterText    string
 	commandFilterText   string
 	filterActive        bool
-	scanPath            string
+	scanOptions         scanner.Options
 	outputPath          string
+	format              executor.Format
 	results             []models.ExecutionResult
 	outputLog           []string
 	err                 error
@@ -125,12 +148,15 @@ type Model struct {
 	completedCommands   int
 	currentExecFolder   string
 	currentExecCommand  string
+	executionMsgs       <-chan tea.Msg
+	cancelExecution     context.CancelFunc
+	cancelling          bool
 }
 
-func NewModel(scanPath, configPath, outputPath string, config *models.Config) Model {
-	folders := scanFolders(scanPath)
+func NewModel(scanOptions scanner.Options, configPath, outputPath string, format executor.Format, config *models.Config) Model {
+	folders := scanner.Scan(scanOptions)
 	if outputPath == "" {
-		outputPath = defaultOutputPath()
+		outputPath = executor.DefaultOutputPath(format)
 	}
 
 	return Model{
@@ -138,6 +164,8 @@ func NewModel(scanPath, configPath, outputPath string, config *models.Config) Mo
 		focus:               foldersFocus,
 		folders:             folders,
 		commands:            config.Commands,
+		cfg:                 config,
+		activeProfile:       -1,
 		selectedCommands:    make(map[int]bool),
 		folderCursorPos:     0,
 		commandCursorPos:    0,
@@ -146,8 +174,9 @@ func NewModel(scanPath, configPath, outputPath string, config *models.Config) Mo
 		folderFilterText:    "",
 		commandFilterText:   "",
 		filterActive:        false,
-		scanPath:            scanPath,
+		scanOptions:         scanOptions,
 		outputPath:          outputPath,
+		format:              format,
 		outputLog:           []string{"Ready to execute commands..."},
 		windowHeight:        0,
 		windowWidth:         0,
@@ -159,30 +188,6 @@ func NewModel(scanPath, configPath, outputPath string, config *models.Config) Mo
 	}
 }
 
-func scanFolders(basePath string) []models.Folder {
-	var folders []models.Folder
-
-	entries, err := os.ReadDir(basePath)
-	if err != nil {
-		return folders
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		fullPath := filepath.Join(basePath, entry.Name())
-		folders = append(folders, models.Folder{
-			Path:     fullPath,
-			Name:     entry.Name(),
-			Selected: false,
-		})
-	}
-
-	return folders
-}
-
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -199,6 +204,7 @@ func (m *Model) reset() {
 		m.folders[i].Selected = false
 	}
 	m.selectedCommands = make(map[int]bool)
+	m.activeProfile = -1
 	m.folderFilterText = ""
 	m.commandFilterText = ""
 	m.filterActive = false
@@ -211,18 +217,76 @@ func (m *Model) reset() {
 	m.addLog("Reset: cleared all selections, filters, and output")
 }
 
+// applyProfile replaces the command selection with the commands of the
+// profile and, when the profile lists folder patterns, the folder selection
+// with the matching folders.
+func (m *Model) applyProfile(index int) {
+	profile := m.cfg.Profiles[index]
+	commands, err := config.ProfileCommands(m.cfg, &profile)
+	if err != nil {
+		m.addLog(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	names := make(map[string]bool)
+	for _, cmd := range commands {
+		names[cmd.Name] = true
+	}
+	m.selectedCommands = make(map[int]bool)
+	for i, cmd := range m.commands {
+		if names[cmd.Name] {
+			m.selectedCommands[i] = true
+		}
+	}
+
+	if len(profile.Folders) > 0 {
+		for i := range m.folders {
+			matched, err := scanner.Match(m.folders[i].Name, profile.Folders)
+			if err != nil {
+				m.addLog(fmt.Sprintf("Error: profile %q: %v", profile.Name, err))
+				return
+			}
+			m.folders[i].Selected = matched
+		}
+	}
+
+	m.activeProfile = index
+	m.addLog(fmt.Sprintf("Profile %q: %d commands, %d folders selected",
+		profile.Name, len(commands), countSelectedFolders(m.folders)))
+}
+
+// rescan reloads the folder list, keeping the selection of folders that are
+// still present.
+func (m *Model) rescan() {
+	selected := make(map[string]bool)
+	for _, folder := range m.folders {
+		if folder.Selected {
+			selected[folder.Path] = true
+		}
+	}
+
+	m.folders = scanner.Scan(m.scanOptions)
+	for i := range m.folders {
+		m.folders[i].Selected = selected[m.folders[i].Path]
+	}
+
+	m.folderCursorPos = 0
+	m.folderScrollOffset = 0
+}
+
 func (m Model) executeCommands() (tea.Model, tea.Cmd) {
 	m.currentView = executingView
 	if m.outputPath == "" {
-		m.outputPath = defaultOutputPath()
+		m.outputPath = executor.DefaultOutputPath(m.format)
 	}
 
 	var selectedCmds []models.Command
-	for i, selected := range m.selectedCommands {
-		if selected && i < len(m.commands) {
-			selectedCmds = append(selectedCmds, m.commands[i])
+	for i, cmd := range m.commands {
+		if m.selectedCommands[i] {
+			selectedCmds = append(selectedCmds, cmd)
 		}
 	}
+	selectedCmds = config.WithDependencies(m.cfg, selectedCmds)
 
 	selectedFolderCount := 0
 	for _, folder := range m.folders {
@@ -236,33 +300,67 @@ func (m Model) executeCommands() (tea.Model, tea.Cmd) {
 	m.currentExecFolder = ""
 	m.currentExecCommand = ""
 
-	return m, m.executeCommandsAsync(selectedCmds)
+	m.results = nil
+	m.cancelling = false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelExecution = cancel
+	m.executionMsgs = m.executeCommandsAsync(ctx, selectedCmds)
+
+	return m, waitForExecutionMsg(m.executionMsgs)
 }
 
-func (m Model) executeCommandsAsync(selectedCmds []models.Command) tea.Cmd {
-	return func() tea.Msg {
-		var results []models.ExecutionResult
+// executeCommandsAsync runs the batch in the background and returns a channel
+// that yields an executionProgressMsg per finished command followed by a
+// single executionCompleteMsg, after which it is closed.
+func (m Model) executeCommandsAsync(ctx context.Context, selectedCmds []models.Command) <-chan tea.Msg {
+	msgs := make(chan tea.Msg)
+	folders := m.folders
+	outputPath := m.outputPath
+	format := m.format
+
+	var writer executor.ReportWriter
+	var writeErr error
+	opts := executor.OptionsFromConfig(m.cfg)
+	opts.OnResult = func(result models.ExecutionResult) {
+		if err := writer.Add(result); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		msgs <- executionProgressMsg{
+			folderName:  result.FolderName,
+			commandName: result.CommandName,
+			result:      result,
+		}
+	}
 
-		for _, folder := range m.folders {
-			if !folder.Selected {
-				continue
-			}
+	go func() {
+		defer close(msgs)
 
-			for _, cmd := range selectedCmds {
-				result := executor.ExecuteCommand(folder, cmd)
-				results = append(results, result)
-			}
+		var err error
+		writer, err = executor.NewReportWriter(format, outputPath)
+		if err != nil {
+			msgs <- executionCompleteMsg{err: err}
+			return
 		}
 
-		err := executor.WriteResults(results, m.outputPath)
+		results := executor.Execute(ctx, folders, selectedCmds, opts)
+		if err := writer.Close(results); err != nil && writeErr == nil {
+			writeErr = err
+		}
 
-		return executionCompleteMsg{
+		msgs <- executionCompleteMsg{
 			results: results,
-			err:     err,
+			err:     writeErr,
 		}
-	}
+	}()
+
+	return msgs
 }
 
-func defaultOutputPath() string {
-	return fmt.Sprintf("multi-cmd-results-%s.md", time.Now().Format("2006-01-02-150405"))
+// waitForExecutionMsg blocks until the next execution message arrives.
+// Progress handlers re-issue it so the stream keeps flowing until completion.
+func waitForExecutionMsg(msgs <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-msgs
+	}
 }
@@ -19,6 +19,9 @@ var (
 			Foreground(lipgloss.Color("#FF0000")).
 			Bold(true)
 
+	stderrStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500"))
+
 	successStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#00FF00")).
 			Bold(true)
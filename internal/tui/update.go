@@ -27,10 +27,15 @@ func (m Model) handleExecutionProgress(msg executionProgressMsg) (tea.Model, tea
 	m.currentExecFolder = msg.folderName
 	m.currentExecCommand = msg.commandName
 	m.results = append(m.results, msg.result)
-	return m, nil
+	return m, waitForExecutionMsg(m.executionMsgs)
 }
 
 func (m Model) handleExecutionComplete(msg executionCompleteMsg) (tea.Model, tea.Cmd) {
+	if m.cancelExecution != nil {
+		m.cancelExecution()
+	}
+	m.cancelExecution = nil
+	m.executionMsgs = nil
 	m.results = msg.results
 	m.err = msg.err
 	m.currentView = doneView
@@ -52,6 +57,10 @@ func (m Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.currentView == executingView {
+		return m.handleExecutingKey(msg)
+	}
+
 	if m.filterActive {
 		return m.handleFilterKey(msg)
 	}
@@ -63,6 +72,12 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.enableFilterMode()
 	case key.Matches(msg, keys.Reset):
 		return m.handleReset()
+	case key.Matches(msg, keys.Hidden):
+		return m.toggleHidden()
+	case key.Matches(msg, keys.Profile):
+		return m.nextProfile()
+	case key.Matches(msg, keys.ProfileN):
+		return m.selectProfile(int(msg.String()[0] - '1'))
 	case key.Matches(msg, keys.Tab), key.Matches(msg, keys.Left), key.Matches(msg, keys.Right):
 		return m.toggleFocus()
 	case key.Matches(msg, keys.Up):
@@ -80,6 +95,19 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// handleExecutingKey only honours cancellation while a batch is running so
+// that no command is left orphaned by quitting the program.
+func (m Model) handleExecutingKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !key.Matches(msg, keys.Cancel) || m.cancelling || m.cancelExecution == nil {
+		return m, nil
+	}
+
+	m.cancelling = true
+	m.cancelExecution()
+	m.addLog("Cancelling execution...")
+	return m, nil
+}
+
 func (m Model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
@@ -143,6 +171,42 @@ func (m Model) handleReset() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) toggleHidden() (tea.Model, tea.Cmd) {
+	if m.currentView != mainView {
+		return m, nil
+	}
+
+	m.scanOptions.IncludeHidden = !m.scanOptions.IncludeHidden
+	m.rescan()
+	if m.scanOptions.IncludeHidden {
+		m.addLog(fmt.Sprintf("Showing hidden folders (%d folders)", len(m.folders)))
+	} else {
+		m.addLog(fmt.Sprintf("Hiding hidden folders (%d folders)", len(m.folders)))
+	}
+	return m, nil
+}
+
+func (m Model) nextProfile() (tea.Model, tea.Cmd) {
+	if len(m.cfg.Profiles) == 0 {
+		m.addLog("No profiles defined in config")
+		return m, nil
+	}
+	return m.selectProfile((m.activeProfile + 1) % len(m.cfg.Profiles))
+}
+
+func (m Model) selectProfile(index int) (tea.Model, tea.Cmd) {
+	if m.currentView != mainView {
+		return m, nil
+	}
+	if index >= len(m.cfg.Profiles) {
+		m.addLog(fmt.Sprintf("No profile %d (%d defined)", index+1, len(m.cfg.Profiles)))
+		return m, nil
+	}
+
+	m.applyProfile(index)
+	return m, nil
+}
+
 func (m Model) toggleFocus() (tea.Model, tea.Cmd) {
 	if m.currentView == mainView {
 		if m.focus == foldersFocus {
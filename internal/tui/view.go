@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ramayac/multi-cmd/internal/executor"
 	"github.com/ramayac/multi-cmd/internal/models"
+	"github.com/ramayac/multi-cmd/internal/scanner"
 )
 
 func (m Model) View() string {
@@ -27,11 +29,10 @@ func (m Model) renderExecutingView() string {
 	percentage := float64(m.completedCommands) / float64(m.totalCommands) * 100
 	progress := fmt.Sprintf("\n\nProgress: %d/%d (%.0f%%)\n", m.completedCommands, m.totalCommands, percentage)
 
-	if m.currentExecFolder != "" {
-		progress += fmt.Sprintf("\nFolder: %s\n", m.currentExecFolder)
-	}
-	if m.currentExecCommand != "" {
-		progress += fmt.Sprintf("Command: %s\n", m.currentExecCommand)
+	// Commands run in parallel, so show the one that finished last rather
+	// than pretending a single command is running
+	if m.currentExecFolder != "" && m.currentExecCommand != "" {
+		progress += fmt.Sprintf("\nLast finished: %s in %s\n", m.currentExecCommand, m.currentExecFolder)
 	}
 
 	barWidth := 40
@@ -47,7 +48,15 @@ func (m Model) renderExecutingView() string {
 	bar += "]"
 	progress += "\n" + bar + "\n"
 
-	return boxStyle.Render(titleStyle.Render("⚙️  Executing...") + progress)
+	help := helpStyle.Render("esc: cancel run")
+	if m.cancelling {
+		help = errorStyle.Render("Cancelling: stopping running commands...")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		boxStyle.Render(titleStyle.Render("⚙️  Executing...")+progress),
+		help,
+	)
 }
 
 func (m Model) renderDoneView() string {
@@ -91,7 +100,7 @@ func (m Model) renderMainView() string {
 			s.WriteString(helpStyle.Render("Filter Commands: " + m.commandFilterText + "█ • esc: cancel • enter: done"))
 		}
 	} else {
-		s.WriteString(helpStyle.Render("↑/↓: navigate • space: toggle • a: all • /: filter • r: reset • tab: switch • enter: execute • q: quit"))
+		s.WriteString(helpStyle.Render("↑/↓: navigate • space: toggle • a: all • /: filter • p/1-9: profile • .: hidden • r: reset • tab: switch • enter: execute • q: quit"))
 	}
 
 	return s.String()
@@ -124,18 +133,14 @@ func (m Model) renderFilterSection(folderWidth, cmdWidth int) string {
 func (m Model) renderFoldersPanel(width int) string {
 	filtered := m.getFilteredFolders()
 
+	items := folderLabels(filtered)
 	selectedFolders := make(map[string]bool)
-	for _, folder := range m.folders {
+	for i, folder := range filtered {
 		if folder.Selected {
-			selectedFolders[folder.Name] = true
+			selectedFolders[items[i]] = true
 		}
 	}
 
-	items := make([]string, len(filtered))
-	for i, folder := range filtered {
-		items[i] = folder.Name
-	}
-
 	selectedCount := 0
 	for _, folder := range m.folders {
 		if folder.Selected {
@@ -155,6 +160,30 @@ func (m Model) renderFoldersPanel(width int) string {
 	)
 }
 
+// folderLabels renders folder names, adding aligned branch and status
+// columns when git metadata is available.
+func folderLabels(folders []models.Folder) []string {
+	nameWidth, branchWidth := 0, 0
+	for _, folder := range folders {
+		if folder.Git == nil {
+			continue
+		}
+		nameWidth = max(nameWidth, lipgloss.Width(folder.Name))
+		branchWidth = max(branchWidth, lipgloss.Width(folder.Git.Branch))
+	}
+
+	labels := make([]string, len(folders))
+	for i, folder := range folders {
+		if folder.Git == nil {
+			labels[i] = folder.Name
+			continue
+		}
+		labels[i] = fmt.Sprintf("%-*s  %-*s  %s",
+			nameWidth, folder.Name, branchWidth, folder.Git.Branch, scanner.GitSummary(folder.Git))
+	}
+	return labels
+}
+
 func (m Model) renderCommandsPanel(width int) string {
 	filtered := m.getFilteredCommands()
 
@@ -170,8 +199,13 @@ func (m Model) renderCommandsPanel(width int) string {
 		items[i] = cmd.Name
 	}
 
+	header := "⚡ Commands"
+	if m.activeProfile >= 0 {
+		header += " · profile: " + m.cfg.Profiles[m.activeProfile].Name
+	}
+
 	return m.renderListPanel(
-		"⚡ Commands",
+		header,
 		items,
 		selectedCommands,
 		m.commandCursorPos,
@@ -356,10 +390,20 @@ func (m Model) executionPanelLines() []string {
 
 	successCount := 0
 	failCount := 0
+	timeoutCount := 0
+	cancelledCount := 0
+	skippedCount := 0
 	for _, result := range m.results {
-		if result.Success {
+		switch {
+		case result.Success:
 			successCount++
-		} else {
+		case result.Status == models.StatusTimedOut:
+			timeoutCount++
+		case result.Status == models.StatusCancelled:
+			cancelledCount++
+		case result.Status == models.StatusSkipped:
+			skippedCount++
+		default:
 			failCount++
 		}
 	}
@@ -372,14 +416,18 @@ func (m Model) executionPanelLines() []string {
 		}
 	}
 
-	lines = append(lines, "✅ Execution Complete", "")
+	if cancelledCount > 0 {
+		lines = append(lines, "⚠️  Execution Cancelled", "")
+	} else {
+		lines = append(lines, "✅ Execution Complete", "")
+	}
 	if m.outputPath != "" {
 		lines = append(lines, fmt.Sprintf("Results written to: %s", m.outputPath))
 	} else {
 		lines = append(lines, "Results file path unavailable")
 	}
-	lines = append(lines, fmt.Sprintf("Executed: %d commands on %d folders | Success: %d | Failed: %d",
-		selectedCmdCount, folderCount, successCount, failCount))
+	lines = append(lines, fmt.Sprintf("Executed: %d commands on %d folders | Success: %d | Failed: %d | Timed out: %d | Cancelled: %d | Skipped: %d",
+		selectedCmdCount, folderCount, successCount, failCount, timeoutCount, cancelledCount, skippedCount))
 
 	if len(m.results) == 0 {
 		return lines
@@ -397,22 +445,52 @@ func (m Model) executionPanelLines() []string {
 		lines = append(lines, "")
 		lines = append(lines, fmt.Sprintf("Command: %s", result.CommandName))
 		lines = append(lines, dimmedStyle.Render(fmt.Sprintf("Executed: %s", result.CommandExecuted)))
-
-		if result.Success {
-			trimmed := strings.TrimRight(result.Output, "\n")
-			if trimmed == "" {
-				lines = append(lines, dimmedStyle.Render("(no output)"))
-			} else {
-				lines = append(lines, strings.Split(trimmed, "\n")...)
+		lines = append(lines, dimmedStyle.Render(executor.FormatTiming(result)))
+		if result.Attempts > 1 {
+			lines = append(lines, dimmedStyle.Render(fmt.Sprintf("Attempts: %d", result.Attempts)))
+			for i, attemptErr := range result.AttemptErrors {
+				lines = append(lines, dimmedStyle.Render(fmt.Sprintf("  Attempt %d: %s", i+1, attemptErr)))
 			}
-		} else {
+		}
+		if files := executor.FormatOutputFiles(result); files != "" {
+			lines = append(lines, dimmedStyle.Render("Full output: "+strings.ReplaceAll(files, "`", "")))
+		}
+
+		if result.Status == models.StatusTimedOut {
+			lines = append(lines, errorStyle.Render(fmt.Sprintf("Timed out: %s", result.Error)))
+		} else if result.Status == models.StatusCancelled {
+			lines = append(lines, dimmedStyle.Render(fmt.Sprintf("Cancelled: %s", result.Error)))
+		} else if result.Status == models.StatusSkipped {
+			lines = append(lines, dimmedStyle.Render(fmt.Sprintf("Skipped: %s", result.Error)))
+		} else if !result.Success {
 			lines = append(lines, errorStyle.Render(fmt.Sprintf("Error: %s", result.Error)))
 		}
+
+		if result.Combined != "" {
+			lines = append(lines, outputLines(result.Combined)...)
+			continue
+		}
+		if result.Success && result.Stdout == "" && result.Stderr == "" {
+			lines = append(lines, dimmedStyle.Render("(no output)"))
+			continue
+		}
+		lines = append(lines, outputLines(result.Stdout)...)
+		for _, line := range outputLines(result.Stderr) {
+			lines = append(lines, stderrStyle.Render(line))
+		}
 	}
 
 	return lines
 }
 
+func outputLines(output string) []string {
+	trimmed := strings.TrimRight(output, "\n")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "\n")
+}
+
 func countSelectedFolders(folders []models.Folder) int {
 	count := 0
 	for _, folder := range folders {